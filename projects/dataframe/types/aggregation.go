@@ -2,7 +2,20 @@ package types
 
 import (
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
 	"strconv"
+	"time"
+)
+
+const (
+	// nil values are left out of the aggregation. This is the default
+	NULL_SKIP nullMode = iota
+	// the result of the aggregation is nil if any of the values is nil
+	NULL_PROPAGATE
+	// nil values are aggregated as 0
+	NULL_AS_ZERO
 )
 
 var (
@@ -10,20 +23,104 @@ var (
 	MIN aggregateFunc = getMin
 	SUM aggregateFunc = getSum
 	MEAN aggregateFunc = getMean
+	// COUNT includes nil values, COUNT_NON_NULL does not. In aggregations, nil values are left out
+	// by default (NULL_SKIP), so COUNT only includes them with NULL_AS_ZERO or NULL_PROPAGATE
 	COUNT aggregateFunc = getCount
+	COUNT_NON_NULL aggregateFunc = getCountNonNull
 	RANGE aggregateFunc = getRange
-	// PERCENTILE(int) etc.
+	MEDIAN aggregateFunc = getMedian
+	// PERCENTILE(p) is the aggregateFunc for the p-th percentile, p being between 0 and 100
+	PERCENTILE = getPercentile
+	// population variance and standard deviation
+	VARIANCE aggregateFunc = getVariance
+	STDDEV aggregateFunc = getStdDev
+	// sample variance and standard deviation, with Bessel's correction
+	VARIANCE_SAMPLE aggregateFunc = getSampleVariance
+	STDDEV_SAMPLE aggregateFunc = getSampleStdDev
+	MODE aggregateFunc = getMode
+	COUNT_DISTINCT aggregateFunc = getCountDistinct
+	FIRST aggregateFunc = getFirst
+	LAST aggregateFunc = getLast
+	COLLECT aggregateFunc = getCollect
 )
 
-// map of column name and the aggregateFunc function to apply to its values
-type aggregation map[string]aggregateFunc
+// aggregateFunc function to apply to the values of a column, the name of the column to hold its result,
+// and how nil values are handled
+type aggregation struct {
+	field string
+	name string
+	fn aggregateFunc
+	nullMode nullMode
+}
+
+// How nil values are handled when aggregating
+type nullMode int
 
 // aggregation function to convert array of values into single value especially during grouping
 type aggregateFunc func([]interface{}) interface{}
 
+// Returns a copy of the aggregation whose result is held in a column of the given name,
+// instead of the column aggregated. This allows a number of aggregations of the same column
+// e.g. df.Col("age").Agg(MAX).As("max_age")
+func (a aggregation) As(name string) aggregation {
+	a.name = name
+	return a
+}
+
+// Returns a copy of the aggregation that handles nil values basing on the given mode.
+// The built-in aggregateFunc functions leave out nil values by default i.e. NULL_SKIP
+func (a aggregation) WithNulls(mode nullMode) aggregation {
+	a.nullMode = mode
+	return a
+}
+
+// Applies the aggregateFunc to the values, handling the nil values basing on the null mode.
+// Counts are never nil, so COUNT, COUNT_NON_NULL and COUNT_DISTINCT ignore NULL_PROPAGATE
+func (a aggregation) apply(values []interface{}) interface{} {
+	switch a.nullMode {
+	case NULL_PROPAGATE:
+		if isCountFunc(a.fn) {
+			break
+		}
 
-// Aggregation function to get the maximum value in the list of values
+		for _, v := range values {
+			if v == nil {
+				return nil
+			}
+		}
+	case NULL_AS_ZERO:
+		zeroed := make([]interface{}, len(values))
+		for i, v := range values {
+			if v == nil {
+				v = 0
+			}
+			zeroed[i] = v
+		}
+		values = zeroed
+	default:
+		nonNil := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			if v != nil {
+				nonNil = append(nonNil, v)
+			}
+		}
+		values = nonNil
+	}
+
+	return a.fn(values)
+}
+
+// Aggregation function to get the maximum value in the list of values.
+// Date-times are compared by time, returning nil if they are mixed with values of other types
 func getMax(values []interface{}) interface{} {
+	if _, isTime := getFirst(values).(time.Time); isTime {
+		if _, latest, isTimes := getTimeBounds(values); isTimes {
+			return latest
+		}
+
+		return nil
+	}
+
 	var a interface{} = nil
 
 	defer func() {
@@ -64,8 +161,17 @@ func getMax(values []interface{}) interface{} {
 	return a
 }
 
-// Aggregation function to get the minimum value in the list of values
+// Aggregation function to get the minimum value in the list of values.
+// Date-times are compared by time, returning nil if they are mixed with values of other types
 func getMin(values []interface{}) interface{} {
+	if _, isTime := getFirst(values).(time.Time); isTime {
+		if earliest, _, isTimes := getTimeBounds(values); isTimes {
+			return earliest
+		}
+
+		return nil
+	}
+
 	var a interface{} = nil
 
 	defer func() {
@@ -146,49 +252,47 @@ func getSum(values []interface{}) interface{} {
 	return a
 }
 
-// Aggregation function to get the mean value in the list of values 
-// It returns nil if there are some nil values
+// Aggregation function to get the mean value in the list of values, nil values aside
+// It returns nil if any of the values (nil aside) is not a number
 func getMean(values []interface{}) interface{} {
 	a := getSum(values)
-
-	defer func() {
-		if r := recover(); r != nil {
-			a = nil
-		}
-	}()
-
-	if a != nil {
-		count := float64(len(values))
-
-		switch a := a.(type) {
-		case int:
-			return float64(a) / count
-		case int8:
-			return float64(a) / count
-		case int16:
-			return float64(a) / count
-		case int32:
-			return float64(a) / count
-		case int64:
-			return float64(a) / count
-		case float32:
-			return float64(a) / count
-		case float64:
-			return float64(a) / count
-		}
+	if a == nil {
+		return nil
 	}
 
-	return a
+	return a.(float64) / float64(getCountNonNull(values).(int))
 }
 
-// Returns the number of items in the values array
+// Returns the number of items in the values array, nil values included
 func getCount(values []interface{}) interface{} {
 	return len(values)
 }
 
+// Returns the number of items in the values array that are not nil
+func getCountNonNull(values []interface{}) interface{} {
+	count := 0
+
+	for _, v := range values {
+		if v != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
 // Returns the difference between the biggest and the smallest value in the values array,
-// if all values are numbers (or nil which are ignored), else it returns nil
+// if all values are numbers (or nil which are ignored), else it returns nil.
+// For date-times, it returns the time.Duration between the earliest and the latest
 func getRange(values []interface{}) interface{} {
+	if _, isTime := getFirst(values).(time.Time); isTime {
+		if earliest, latest, isTimes := getTimeBounds(values); isTimes {
+			return latest.Sub(earliest)
+		}
+
+		return nil
+	}
+
 	var max interface{} = nil
 	var min interface{} = nil
 
@@ -256,10 +360,201 @@ func getRange(values []interface{}) interface{} {
 	return nil
 }
 
+// Aggregation function to get the median of the values.
+// It returns nil if any of the values (nil aside) is not a number
+func getMedian(values []interface{}) interface{} {
+	return getPercentile(50)(values)
+}
+
+// Returns an aggregation function to get the p-th percentile of the values, p being between 0 and 100,
+// interpolating linearly between the two nearest values.
+// The aggregation function returns nil if any of the values (nil aside) is not a number
+func getPercentile(p float64) aggregateFunc {
+	return func(values []interface{}) interface{} {
+		numbers, ok := getNumbers(values)
+		if !ok || len(numbers) == 0 || p < 0 || p > 100 {
+			return nil
+		}
+
+		sort.Float64s(numbers)
+
+		rank := p / 100 * float64(len(numbers)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+
+		return numbers[lower] + (rank-float64(lower))*(numbers[upper]-numbers[lower])
+	}
+}
+
+// Aggregation function to get the population variance of the values.
+// It returns nil if any of the values (nil aside) is not a number
+func getVariance(values []interface{}) interface{} {
+	return getVarianceWithDdof(values, 0)
+}
+
+// Aggregation function to get the sample variance of the values.
+// It returns nil if any of the values (nil aside) is not a number or if there are fewer than two values
+func getSampleVariance(values []interface{}) interface{} {
+	return getVarianceWithDdof(values, 1)
+}
+
+// Aggregation function to get the population standard deviation of the values.
+// It returns nil if any of the values (nil aside) is not a number
+func getStdDev(values []interface{}) interface{} {
+	if variance := getVariance(values); variance != nil {
+		return math.Sqrt(variance.(float64))
+	}
+
+	return nil
+}
+
+// Aggregation function to get the sample standard deviation of the values.
+// It returns nil if any of the values (nil aside) is not a number or if there are fewer than two values
+func getSampleStdDev(values []interface{}) interface{} {
+	if variance := getSampleVariance(values); variance != nil {
+		return math.Sqrt(variance.(float64))
+	}
+
+	return nil
+}
+
+// Aggregation function to get the most frequent value (nil aside). Numbers are compared as float64 values
+// and returned as such. If a number of values are equally frequent, the first of them is returned
+func getMode(values []interface{}) interface{} {
+	var a interface{} = nil
+	counts := map[interface{}]int{}
+	keys := []interface{}{}
+	firstValues := []interface{}{}
+
+	for _, v := range values {
+		if v == nil { continue }
+
+		key := getDistinctKey(v)
+		if _, ok := counts[key]; !ok {
+			keys = append(keys, key)
+			firstValues = append(firstValues, v)
+		}
+		counts[key]++
+	}
+
+	maxCount := 0
+	for i, key := range keys {
+		if counts[key] > maxCount {
+			maxCount = counts[key]
+			a = firstValues[i]
+		}
+	}
+
+	if number, isNumber := toFloat64(a); isNumber {
+		return number
+	}
+
+	return a
+}
+
+// Returns the number of distinct values (nil aside) in the values array.
+// Numbers of different types but equal value e.g. 1 and 1.0 are the same value
+func getCountDistinct(values []interface{}) interface{} {
+	distinct := map[interface{}]struct{}{}
+
+	for _, v := range values {
+		if v == nil { continue }
+		distinct[getDistinctKey(v)] = struct{}{}
+	}
+
+	return len(distinct)
+}
+
+// Returns the first value in the values array that is not nil
+func getFirst(values []interface{}) interface{} {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// Returns the last value in the values array that is not nil
+func getLast(values []interface{}) interface{} {
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i] != nil {
+			return values[i]
+		}
+	}
+
+	return nil
+}
+
+// Returns the values that are not nil, as a list, in their original order
+func getCollect(values []interface{}) interface{} {
+	list := make([]interface{}, 0, len(values))
+
+	for _, v := range values {
+		if v != nil {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
 /*
 *	Helpers
 */
 
+// Returns whether the aggregateFunc is one of the built-in counts
+func isCountFunc(fn aggregateFunc) bool {
+	return isSameFunc(fn, getCount) || isSameFunc(fn, getCountNonNull) || isSameFunc(fn, getCountDistinct)
+}
+
+// The names of the built-in aggregates. PERCENTILE is named the same whatever its percentile
+var aggregateNames = map[string]aggregateFunc{
+	"max": getMax, "min": getMin, "sum": getSum, "mean": getMean, "count": getCount,
+	"count_non_null": getCountNonNull, "range": getRange, "median": getMedian, "percentile": getPercentile(50),
+	"variance": getVariance, "stddev": getStdDev, "variance_sample": getSampleVariance,
+	"stddev_sample": getSampleStdDev, "mode": getMode, "count_distinct": getCountDistinct,
+	"first": getFirst, "last": getLast, "collect": getCollect,
+}
+
+// Returns the name of the aggregateFunc if it is built in, or else an empty string
+func getAggregateName(fn aggregateFunc) string {
+	for name, builtIn := range aggregateNames {
+		if isSameFunc(fn, builtIn) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// Returns the earliest and the latest of the values, and whether all the values (nil aside) are date-times
+func getTimeBounds(values []interface{}) (time.Time, time.Time, bool) {
+	var earliest, latest time.Time
+	isFirst := true
+
+	for _, v := range values {
+		if v == nil { continue }
+
+		t, isTime := v.(time.Time)
+		if !isTime {
+			return earliest, latest, false
+		}
+
+		if isFirst || t.Before(earliest) { earliest = t }
+		if isFirst || t.After(latest) { latest = t }
+		isFirst = false
+	}
+
+	return earliest, latest, !isFirst
+}
+
+// Returns whether the two aggregateFunc functions are the same function
+func isSameFunc(first aggregateFunc, second aggregateFunc) bool {
+	return reflect.ValueOf(first).Pointer() == reflect.ValueOf(second).Pointer()
+}
+
 // Converts a given value of unknown type to float64
 func convertToFloat64(value interface{}) float64 {
 	v := fmt.Sprintf("%v", value)
@@ -267,16 +562,90 @@ func convertToFloat64(value interface{}) float64 {
 	return valueAsFloat
 }
 
-// Merges a slice of aggregations into one aggregation.
-// Inorder to have only one aggregation per column, only the last aggregateFunc passed for that column
+// Converts the values that are not nil to float64, returning false if any of them is not a number
+func getNumbers(values []interface{}) ([]float64, bool) {
+	numbers := make([]float64, 0, len(values))
+
+	for _, v := range values {
+		if v == nil { continue }
+
+		number, ok := toFloat64(v)
+		if !ok {
+			return nil, false
+		}
+
+		numbers = append(numbers, number)
+	}
+
+	return numbers, true
+}
+
+// Computes the variance of the values, dividing the sum of squared deviations by the count less ddof
+// (the delta degrees of freedom). It returns nil if any of the values (nil aside) is not a number
+// or if the count is not more than ddof
+func getVarianceWithDdof(values []interface{}, ddof int) interface{} {
+	numbers, ok := getNumbers(values)
+	if !ok || len(numbers) <= ddof {
+		return nil
+	}
+
+	mean := 0.0
+	for _, number := range numbers {
+		mean += number
+	}
+	mean /= float64(len(numbers))
+
+	sumOfSquares := 0.0
+	for _, number := range numbers {
+		sumOfSquares += (number - mean) * (number - mean)
+	}
+
+	return sumOfSquares / float64(len(numbers)-ddof)
+}
+
+// Returns a key that is equal for values that are the same, to be used in maps.
+// Integers and integral floats are converted to int64, or uint64 beyond it, so that large integers keep
+// their exact values, other floats are converted to float64, and values that cannot be map keys
+// e.g. slices are formatted as strings
+func getDistinctKey(value interface{}) interface{} {
+	if number, ok := toInt64(value); ok {
+		return number
+	}
+
+	switch v := value.(type) {
+	case uint:
+		return uint64(v)
+	case uint64:
+		return v
+	}
+
+	if number, ok := toExactFloat64(value); ok {
+		if number == math.Trunc(number) && number >= -(1 << 63) && number < 1 << 63 {
+			return int64(number)
+		}
+
+		if number == math.Trunc(number) && number >= 0 && number < 1 << 64 {
+			return uint64(number)
+		}
+
+		return number
+	}
+
+	if !reflect.TypeOf(value).Comparable() {
+		return fmt.Sprintf("%T:%v", value, value)
+	}
+
+	return value
+}
+
+// Merges a slice of aggregations into a map of result name and aggregation.
+// Inorder to have only one aggregation per resulting column, only the last aggregation passed for that name
 // is kept
-func mergeAggregations(aggs []aggregation) aggregation {
-	res := aggregation{}
+func mergeAggregations(aggs []aggregation) map[string]aggregation {
+	res := map[string]aggregation{}
 
 	for _, agg 	:= range aggs {
-		for key, v := range agg {
-			res[key] = v
-		}
+		res[agg.name] = agg
 	}
 
 	return res
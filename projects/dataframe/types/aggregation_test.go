@@ -1,6 +1,12 @@
 package types
 
-import "testing"
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
 
 // MAX should return the maximum value (as a float64 value) in a given list of items
 func TestMAX(t *testing.T)  {
@@ -33,6 +39,14 @@ func TestMAX(t *testing.T)  {
 			expected: 98.0,
 		},
 		
+		{
+			input: []interface{}{time.Date(2021, time.January, 5, 0, 0, 0, 0, time.UTC), nil, time.Date(2021, time.January, 20, 0, 0, 0, 0, time.UTC), time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC)},
+			expected: time.Date(2021, time.January, 20, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			input: []interface{}{time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), 5},
+			expected: nil,
+		},
 	}
 
 	for _, tr := range testData {
@@ -74,6 +88,14 @@ func TestMIN(t *testing.T)  {
 			expected: 4.0,
 		},
 		
+		{
+			input: []interface{}{time.Date(2021, time.January, 5, 0, 0, 0, 0, time.UTC), nil, time.Date(2021, time.January, 20, 0, 0, 0, 0, time.UTC), time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC)},
+			expected: time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			input: []interface{}{time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), 5},
+			expected: nil,
+		},
 	}
 
 	for _, tr := range testData {
@@ -127,7 +149,7 @@ func TestSUM(t *testing.T)  {
 }
 
 // MEAN should return the mean (as a float64 value) of the given list of items.
-// It returns nil if the values are not numbers (nil values are treated as zero)
+// It returns nil if the values are not numbers (nil values are left out)
 func TestMEAN(t *testing.T)  {
 	type testRecord struct {
 		input []interface{};
@@ -155,7 +177,11 @@ func TestMEAN(t *testing.T)  {
 			expected: 89.07725,
 		},		{
 			input: []interface{}{80, 78, 98, 4, nil, 7},
-			expected: 44.5,
+			expected: 53.4,
+		},
+		{
+			input: []interface{}{nil, nil},
+			expected: nil,
 		},
 		
 	}
@@ -242,6 +268,14 @@ func TestRANGE(t *testing.T)  {
 			expected: 94.0,
 		},
 		
+		{
+			input: []interface{}{time.Date(2021, time.January, 5, 0, 0, 0, 0, time.UTC), nil, time.Date(2021, time.January, 20, 0, 0, 0, 0, time.UTC), time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC)},
+			expected: 18 * 24 * time.Hour,
+		},
+		{
+			input: []interface{}{time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), 5},
+			expected: nil,
+		},
 	}
 
 	for _, tr := range testData {
@@ -252,38 +286,283 @@ func TestRANGE(t *testing.T)  {
 	}
 }
 
-// mergeAggregations should merge an aggregation list into a single aggregation 
-// ensuring that the last aggregateFunc to be attached to a given column is the one kept,
+// COUNT_NON_NULL should return the number of items in the list of values, excluding nils
+func TestCOUNT_NON_NULL(t *testing.T)  {
+	type testRecord struct {
+		input []interface{};
+		expected interface{}
+	}
+
+	testData := []testRecord{
+		{
+			input: []interface{}{"hi", nil, "hello", nil},
+			expected: 2,
+		},
+		{
+			input: []interface{}{80, 78, 98, 4, nil, 7},
+			expected: 5,
+		},
+		{
+			input: []interface{}{nil},
+			expected: 0,
+		},
+	}
+
+	for _, tr := range testData {
+		got := COUNT_NON_NULL(tr.input)
+		if got != tr.expected {
+			t.Fatalf("expected %v; got %v", tr.expected, got)
+		}
+	}
+}
+
+// An aggregation should leave out nil values by default, return nil if any value is nil with NULL_PROPAGATE
+// (except for counts, which are never nil), and aggregate nil values as 0 with NULL_AS_ZERO
+func TestAggregation_WithNulls(t *testing.T)  {
+	col := newColumn("age", IntType)
+	withNils := []interface{}{4, nil, 8, nil}
+	withoutNils := []interface{}{4, 8}
+
+	type testRecord struct {
+		agg aggregation;
+		input []interface{};
+		expected interface{}
+	}
+
+	testData := []testRecord{
+		{agg: col.Agg(MEAN), input: withNils, expected: 6.0},
+		{agg: col.Agg(MEAN).WithNulls(NULL_SKIP), input: withNils, expected: 6.0},
+		{agg: col.Agg(MEAN).WithNulls(NULL_PROPAGATE), input: withNils, expected: nil},
+		{agg: col.Agg(MEAN).WithNulls(NULL_PROPAGATE), input: withoutNils, expected: 6.0},
+		{agg: col.Agg(MEAN).WithNulls(NULL_AS_ZERO), input: withNils, expected: 3.0},
+		{agg: col.Agg(MIN).WithNulls(NULL_AS_ZERO), input: withNils, expected: 0.0},
+		{agg: col.Agg(COUNT_NON_NULL), input: withNils, expected: 2},
+		{agg: col.Agg(COUNT_NON_NULL).WithNulls(NULL_PROPAGATE), input: withNils, expected: 2},
+		{agg: col.Agg(COUNT_NON_NULL).WithNulls(NULL_AS_ZERO), input: withNils, expected: 4},
+		{agg: col.Agg(COUNT), input: withNils, expected: 2},
+		{agg: col.Agg(COUNT).WithNulls(NULL_PROPAGATE), input: withNils, expected: 4},
+		{agg: col.Agg(COUNT).WithNulls(NULL_AS_ZERO), input: withNils, expected: 4},
+		{agg: col.Agg(COUNT_DISTINCT).WithNulls(NULL_PROPAGATE), input: withNils, expected: 2},
+		{agg: col.Agg(MEDIAN).WithNulls(NULL_AS_ZERO), input: withNils, expected: 2.0},
+		{agg: col.Agg(LAST).WithNulls(NULL_AS_ZERO), input: withNils, expected: 0},
+	}
+
+	for i, tr := range testData {
+		got := tr.agg.apply(tr.input)
+		if got != tr.expected {
+			t.Fatalf("record %d: expected %v; got %v", i, tr.expected, got)
+		}
+	}
+
+	// the input values are not mutated
+	if !utils.AreSliceEqual(withNils, []interface{}{4, nil, 8, nil}) {
+		t.Fatalf("input values should not change, got %v", withNils)
+	}
+}
+
+// MEDIAN and PERCENTILE should return the interpolated percentile (as a float64 value) of the given list of items.
+// They return nil if the values are not numbers (nil values are ignored)
+func TestPERCENTILE(t *testing.T)  {
+	type testRecord struct {
+		agg aggregateFunc;
+		input []interface{};
+		expected interface{}
+	}
+
+	testData := []testRecord{
+		{
+			agg: MEDIAN,
+			input: []interface{}{"hi", "hello", "yoohoo", "salut"},
+			expected: nil,
+		},
+		{
+			agg: MEDIAN,
+			input: []interface{}{4, 1, 2.5},
+			expected: 2.5,
+		},
+		{
+			agg: MEDIAN,
+			input: []interface{}{80, 78, 98, 4, nil, 7},
+			expected: 78.0,
+		},
+		{
+			agg: MEDIAN,
+			input: []interface{}{nil, nil},
+			expected: nil,
+		},
+		{
+			agg: PERCENTILE(25),
+			input: []interface{}{10, 20, 30, 40, 50},
+			expected: 20.0,
+		},
+		{
+			agg: PERCENTILE(90),
+			input: []interface{}{int64(10), 20.0, int8(30), 40, 50},
+			expected: 46.0,
+		},
+		{
+			agg: PERCENTILE(100),
+			input: []interface{}{10, 20, 30, nil},
+			expected: 30.0,
+		},
+		{
+			agg: PERCENTILE(101),
+			input: []interface{}{10, 20, 30},
+			expected: nil,
+		},
+	}
+
+	for i, tr := range testData {
+		got := tr.agg(tr.input)
+		if got != tr.expected {
+			t.Fatalf("record %d: expected %v; got %v", i, tr.expected, got)
+		}
+	}
+}
+
+// VARIANCE, STDDEV, VARIANCE_SAMPLE and STDDEV_SAMPLE should return the population and sample statistics
+// (as float64 values) of the given list of items. They return nil if the values are not numbers (nil values are ignored)
+func TestVARIANCE(t *testing.T)  {
+	type testRecord struct {
+		agg aggregateFunc;
+		input []interface{};
+		expected interface{}
+	}
+
+	input := []interface{}{2, 4, 4, 4, nil, 5, 5, 7.0, 9}
+
+	testData := []testRecord{
+		{agg: VARIANCE, input: input, expected: 4.0},
+		{agg: STDDEV, input: input, expected: 2.0},
+		{agg: VARIANCE_SAMPLE, input: input, expected: 32.0 / 7},
+		{agg: STDDEV_SAMPLE, input: input, expected: math.Sqrt(32.0 / 7)},
+		{agg: VARIANCE, input: []interface{}{3}, expected: 0.0},
+		{agg: VARIANCE_SAMPLE, input: []interface{}{3, nil}, expected: nil},
+		{agg: STDDEV_SAMPLE, input: []interface{}{3}, expected: nil},
+		{agg: STDDEV, input: []interface{}{1, "hello", 5}, expected: nil},
+		{agg: VARIANCE, input: []interface{}{}, expected: nil},
+	}
+
+	for i, tr := range testData {
+		got := tr.agg(tr.input)
+		if tr.expected == nil || got == nil {
+			if got != tr.expected {
+				t.Fatalf("record %d: expected %v; got %v", i, tr.expected, got)
+			}
+			continue
+		}
+
+		if math.Abs(got.(float64) - tr.expected.(float64)) > 1e-9 {
+			t.Fatalf("record %d: expected %v; got %v", i, tr.expected, got)
+		}
+	}
+}
+
+// MODE should return the most frequent value, COUNT_DISTINCT the number of distinct values,
+// and FIRST and LAST the first and last values, nil values being ignored
+func TestMODE(t *testing.T)  {
+	type testRecord struct {
+		agg aggregateFunc;
+		input []interface{};
+		expected interface{}
+	}
+
+	testData := []testRecord{
+		{agg: MODE, input: []interface{}{"hi", "yoo", nil, nil, "yoo", "hi", "yoo"}, expected: "yoo"},
+		{agg: MODE, input: []interface{}{1, 2.0, int64(2), 1.0}, expected: 1.0},
+		{agg: MODE, input: []interface{}{nil, nil}, expected: nil},
+		{agg: COUNT_DISTINCT, input: []interface{}{1, 1.0, int8(1), 2, "1", nil, nil}, expected: 3},
+		{agg: COUNT_DISTINCT, input: []interface{}{[]int{1}, []int{1}, []int{2}}, expected: 2},
+		{agg: COUNT_DISTINCT, input: []interface{}{}, expected: 0},
+		{agg: COUNT_DISTINCT, input: []interface{}{int64(1 << 53), int64(1 << 53 + 1), 1 << 53, uint64(1 << 63)}, expected: 3},
+		{agg: MODE, input: []interface{}{int64(1 << 53 + 1), int64(1 << 53), int64(1 << 53)}, expected: float64(1 << 53)},
+		{agg: FIRST, input: []interface{}{nil, 4, "hi", nil}, expected: 4},
+		{agg: FIRST, input: []interface{}{nil}, expected: nil},
+		{agg: LAST, input: []interface{}{nil, 4, "hi", nil}, expected: "hi"},
+		{agg: LAST, input: []interface{}{}, expected: nil},
+	}
+
+	for i, tr := range testData {
+		got := tr.agg(tr.input)
+		if got != tr.expected {
+			t.Fatalf("record %d: expected %v; got %v", i, tr.expected, got)
+		}
+	}
+}
+
+// COLLECT should gather the values that are not nil into a list, in their original order
+func TestCOLLECT(t *testing.T)  {
+	type testRecord struct {
+		input []interface{};
+		expected []interface{}
+	}
+
+	testData := []testRecord{
+		{
+			input: []interface{}{"hi", nil, 4, 2.5},
+			expected: []interface{}{"hi", 4, 2.5},
+		},
+		{
+			input: []interface{}{nil},
+			expected: []interface{}{},
+		},
+	}
+
+	for _, tr := range testData {
+		got := COLLECT(tr.input).([]interface{})
+		if !utils.AreSliceEqual(got, tr.expected) {
+			t.Fatalf("expected %v; got %v", tr.expected, got)
+		}
+	}
+}
+
+// mergeAggregations should merge an aggregation list into a map of aggregations by result name
+// ensuring that the last aggregation to be given a name is the one kept,
 // the previous ones are overwritten, to avoid ambiguity
 func TestMergeAggregations(t *testing.T)  {
+	hi := newColumn("hi", IntType)
+	yoo := newColumn("yoo", IntType)
+	an := newColumn("an", IntType)
+
 	type testRecord struct {
 		input []aggregation;
-		expected aggregation
+		expected map[string]aggregation
 	}
 
 	testData := []testRecord{
 		{
-			input: []aggregation{{"hi": MAX}, {"hi": MIN, "yoo": RANGE}, {"hi": SUM, "an": RANGE}, {"an": MIN}},
-			expected: aggregation{
-				"hi": SUM,
-				"yoo": RANGE,
-				"an": MIN,
+			input: []aggregation{hi.Agg(MAX), hi.Agg(MIN), yoo.Agg(RANGE), hi.Agg(SUM), an.Agg(RANGE), an.Agg(MIN)},
+			expected: map[string]aggregation{
+				"hi": hi.Agg(SUM),
+				"yoo": yoo.Agg(RANGE),
+				"an": an.Agg(MIN),
 			},
-		},		
+		},
+		{
+			input: []aggregation{hi.Agg(MAX).As("max_hi"), hi.Agg(MIN).As("min_hi"), hi.Agg(SUM), yoo.Agg(COUNT).As("max_hi")},
+			expected: map[string]aggregation{
+				"max_hi": yoo.Agg(COUNT),
+				"min_hi": hi.Agg(MIN),
+				"hi": hi.Agg(SUM),
+			},
+		},
 	}
 
 	sampleArray := []interface{}{2, 1, 45, 6}
 
 	for _, tr := range testData {
 		res := mergeAggregations(tr.input)
+		if len(res) != len(tr.expected) {
+			t.Fatalf("expected %d aggregations; got %d", len(tr.expected), len(res))
+		}
 
 		for key, agg := range tr.expected {
-			got := res[key](sampleArray)
-			expected := agg(sampleArray)
+			got := res[key].fn(sampleArray)
+			expected := agg.fn(sampleArray)
 
-			if got != expected {
-				t.Fatalf("for key '%s', expected %v; got %v",key,  agg, res[key])
+			if got != expected || res[key].field != agg.field {
+				t.Fatalf("for key '%s', expected %v of '%s'; got %v of '%s'", key, expected, agg.field, got, res[key].field)
 			}
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,62 @@
+package types
+
+// A packed list of flags, one bit per row, used to mark which rows of a typed column hold values (are not nil)
+type bitmap []uint64
+
+// Returns the flag at the given position. Positions beyond the bitmap are false
+func (b bitmap) get(position int) bool {
+	word := position / 64
+	if word >= len(b) {
+		return false
+	}
+
+	return b[word]&(1<<uint(position%64)) != 0
+}
+
+// Sets the flag at the given position, growing the bitmap if necessary
+func (b *bitmap) set(position int, flag bool) {
+	word := position / 64
+
+	for len(*b) <= word {
+		*b = append(*b, 0)
+	}
+
+	if flag {
+		(*b)[word] |= 1 << uint(position%64)
+	} else {
+		(*b)[word] &^= 1 << uint(position%64)
+	}
+}
+
+// Returns a new bitmap whose flag at position i is the flag at position positions[i] of this bitmap
+func (b bitmap) take(positions []int) bitmap {
+	taken := make(bitmap, (len(positions)+63)/64)
+
+	for i, position := range positions {
+		if b.get(position) {
+			taken[i/64] |= 1 << uint(i%64)
+		}
+	}
+
+	return taken
+}
+
+// Returns a copy of the bitmap that shares no memory with it
+func (b bitmap) copy() bitmap {
+	return append(bitmap(nil), b...)
+}
+
+// Returns a bitmap with only the first n flags of this bitmap, the others being cleared
+func (b bitmap) truncate(n int) bitmap {
+	words := (n + 63) / 64
+	if words > len(b) {
+		words = len(b)
+	}
+
+	truncated := append(bitmap(nil), b[:words]...)
+	if n%64 != 0 && words == (n+63)/64 && words > 0 {
+		truncated[words-1] &= (1 << uint(n%64)) - 1
+	}
+
+	return truncated
+}
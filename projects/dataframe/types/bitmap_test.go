@@ -0,0 +1,59 @@
+package types
+
+import "testing"
+
+// set and get should set and read flags at any position, growing the bitmap when needed
+func TestBitmap_setAndGet(t *testing.T)  {
+	b := bitmap{}
+	positions := []int{0, 3, 63, 64, 130}
+
+	for _, position := range positions {
+		b.set(position, true)
+	}
+
+	b.set(3, false)
+	expected := map[int]bool{0: true, 1: false, 3: false, 63: true, 64: true, 130: true, 131: false, 500: false}
+
+	for position, flag := range expected {
+		if b.get(position) != flag {
+			t.Fatalf("position %d expected %v; got %v", position, flag, b.get(position))
+		}
+	}
+}
+
+// take should return a new bitmap whose flag at i is the flag at positions[i]
+func TestBitmap_take(t *testing.T)  {
+	b := bitmap{}
+	b.set(1, true)
+	b.set(70, true)
+
+	taken := b.take([]int{70, 0, 1})
+	expected := []bool{true, false, true}
+
+	for i, flag := range expected {
+		if taken.get(i) != flag {
+			t.Fatalf("position %d expected %v; got %v", i, flag, taken.get(i))
+		}
+	}
+}
+
+// truncate should keep the flags before n and clear the rest
+func TestBitmap_truncate(t *testing.T)  {
+	b := bitmap{}
+	for _, position := range []int{0, 63, 64, 65, 130} {
+		b.set(position, true)
+	}
+
+	truncated := b.truncate(65)
+	expected := map[int]bool{0: true, 63: true, 64: true, 65: false, 130: false}
+
+	for position, flag := range expected {
+		if truncated.get(position) != flag {
+			t.Fatalf("position %d expected %v; got %v", position, flag, truncated.get(position))
+		}
+	}
+
+	if !b.get(130) {
+		t.Fatalf("expected the original bitmap to be unchanged")
+	}
+}
@@ -0,0 +1,211 @@
+package types
+
+import "sync"
+
+const (
+	// the record was added
+	INSERTED changeType = iota
+	// the record existed and its values changed
+	UPDATED
+	// the record was removed
+	DELETED
+)
+
+// How a record was changed by a mutation
+type changeType int
+
+func (c changeType) String() string {
+	switch c {
+	case INSERTED:
+		return "inserted"
+	case UPDATED:
+		return "updated"
+	case DELETED:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// A record changed by a mutation
+type RecordChange struct {
+	Type changeType
+	// the primary key of the record
+	Key string
+	// the values of the record before the change, nil if it was inserted
+	Before map[string]interface{}
+	// the values of the record after the change, nil if it was deleted
+	After map[string]interface{}
+}
+
+// The records changed by a mutation of a dataframe, in the order they were changed
+type ChangeEvent struct {
+	// the mutation, as in the Operation of its Version e.g. "insert" or "transaction: insert, delete"
+	Operation string
+	// the number of the version made by the mutation
+	Version int
+	Changes []RecordChange
+}
+
+// The subscribers of a dataframe or a SyncDataframe
+type observers struct {
+	mu sync.Mutex;
+	subscribers []*subscriber;
+}
+
+type subscriber struct {
+	notify func(ChangeEvent);
+}
+
+// A subscriber that sends the events to a buffered channel
+type chanSubscriber struct {
+	// held while sending, so that the channel is not closed in the middle of a send
+	mu sync.Mutex;
+	ch chan ChangeEvent;
+	// closed when the subscription ends, to stop any blocked send
+	done chan struct{};
+	closeOnce sync.Once;
+}
+
+// Calls fn with the changes of every later Insert, Update, Delete, Merge, Clear or committed transaction,
+// synchronously after the mutation, in the order of subscription. Rolled back changes are never sent.
+// It returns a function that ends the subscription
+func (d *Dataframe) Subscribe(fn func(ChangeEvent)) func() {
+	if d.observers == nil {
+		d.observers = &observers{}
+	}
+
+	return d.observers.add(fn)
+}
+
+// Sends the changes of every later Insert, Update, Delete, Merge, Clear or committed transaction
+// to the returned channel, which buffers up to size events. Mutations wait while the buffer is full.
+// It also returns a function that ends the subscription and closes the channel
+func (d *Dataframe) SubscribeChan(size int) (<-chan ChangeEvent, func()) {
+	return subscribeChan(d.Subscribe, size)
+}
+
+/*
+* Helpers
+*/
+
+// Subscribes with subscribe a subscriber that sends the events to a channel which buffers up to size events,
+// returning the channel and a function that ends the subscription and closes the channel
+func subscribeChan(subscribe func(fn func(ChangeEvent)) func(), size int) (<-chan ChangeEvent, func()) {
+	s := &chanSubscriber{ch: make(chan ChangeEvent, size), done: make(chan struct{})}
+	unsubscribe := subscribe(s.send)
+
+	return s.ch, func() {
+		unsubscribe()
+		s.close()
+	}
+}
+
+// Adds the subscriber, returning a function that removes it
+func (o *observers) add(fn func(ChangeEvent)) func() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	s := &subscriber{notify: fn}
+	o.subscribers = append(o.subscribers, s)
+
+	return func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		for i, other := range o.subscribers {
+			if other == s {
+				// a new slice, as notify may be ranging over the old one
+				o.subscribers = append(append([]*subscriber{}, o.subscribers[:i]...), o.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Returns the current subscribers. They are called without holding the lock,
+// so that they can subscribe or unsubscribe themselves
+func (o *observers) getSubscribers() []*subscriber {
+	if o == nil {
+		return nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.subscribers
+}
+
+// Sends the event to each of the subscribers, in the order of subscription
+func (o *observers) notify(event ChangeEvent) {
+	for _, s := range o.getSubscribers() {
+		s.notify(event)
+	}
+}
+
+// Sends the event to the channel, unless the subscription has ended
+func (s *chanSubscriber) send(event ChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+	case s.ch <- event:
+	}
+}
+
+// Ends the subscription, stopping any blocked send before closing the channel
+func (s *chanSubscriber) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+// Returns whether any subscribers should be told about the changes of mutations
+func (d *Dataframe) hasSubscribers() bool {
+	return len(d.observers.getSubscribers()) > 0
+}
+
+// Records the current state of the dataframe as a new version, and sends the changes that made it to the subscribers
+func (d *Dataframe) commitVersion(operation string, changes []RecordChange) {
+	d.recordVersion(operation)
+
+	d.observers.notify(ChangeEvent{Operation: operation, Version: d.Version(), Changes: changes})
+}
+
+// Returns the changes of the given type for the records at the positions whose values in filter are true,
+// with their current values as the values before the change
+func (d *Dataframe) getChanges(changeType changeType, filter []bool) []RecordChange {
+	keys := d.Keys()
+	indices := d.getIndicesInOrder()
+	changes := []RecordChange{}
+
+	for position, isChanged := range filter {
+		if isChanged && position < len(keys) {
+			changes = append(changes, RecordChange{Type: changeType, Key: keys[position], Before: d.getRecordAt(indices[position])})
+		}
+	}
+
+	return changes
+}
+
+// Sets the values of the records after the change to their current values
+func (d *Dataframe) setValuesAfter(changes []RecordChange) {
+	for i := range changes {
+		changes[i].After = d.getRecordAt(d.index[changes[i].Key])
+	}
+}
+
+// Returns the values of all the fields at the given row
+func (d *Dataframe) getRecordAt(row int) map[string]interface{} {
+	record := make(map[string]interface{}, len(d.cols))
+	for name, col := range d.cols {
+		record[name] = col.get(row)
+	}
+
+	return record
+}
@@ -0,0 +1,221 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Subscribers should receive the records changed by each mutation, with their values before and after
+func TestDataframe_Subscribe(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	other, err := FromArray([]map[string]interface{}{
+		{"first name": "Ann", "last name": "Poe", "age": 21, "location": "Gulu"},
+	}, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	events := []ChangeEvent{}
+	unsubscribe := df.Subscribe(func(event ChangeEvent) { events = append(events, event) })
+
+	type testRecord struct {
+		mutate func() error;
+		operation string;
+		// each change as "type key before-age after-age"
+		expected []string;
+	}
+
+	testData := []testRecord{
+		{
+			mutate: func() error {
+				return df.Insert([]map[string]interface{}{
+					{"first name": "Tom", "last name": "Poe", "age": 70, "location": "Mbale"},
+					{"first name": "John", "last name": "Doe", "age": 31},
+				})
+			},
+			operation: "insert",
+			expected: []string{"inserted Tom_Poe <nil> 70", "updated John_Doe 30 31"},
+		},
+		{
+			mutate: func() error {
+				return df.Update(df.Col("age").GreaterThan(55), map[string]interface{}{"location": "Jinja"})
+			},
+			operation: "update",
+			expected: []string{"updated Ruth_Roe 60 60", "updated Tom_Poe 70 70"},
+		},
+		{
+			mutate: func() error {
+				return df.Delete(df.Col("last name").Equals("Doe"))
+			},
+			operation: "delete",
+			expected: []string{"deleted John_Doe 31 <nil>", "deleted Jane_Doe 50 <nil>", "deleted Paul_Doe 19 <nil>"},
+		},
+		{
+			mutate: func() error {
+				return df.Merge(other)
+			},
+			operation: "merge",
+			expected: []string{"inserted Ann_Poe <nil> 21"},
+		},
+		{
+			mutate: func() error {
+				tx := df.Begin()
+				err := tx.Delete(df.Col("first name").Equals("Ann"))
+				if err != nil {
+					return err
+				}
+
+				return tx.Rollback()
+			},
+		},
+		{
+			mutate: func() error {
+				return df.WithStrictness(STRICT).Insert([]map[string]interface{}{{"first name": "Ben", "last name": "Poe", "age": "old"}})
+			},
+		},
+		{
+			mutate: func() error {
+				df.Clear()
+				return nil
+			},
+			operation: "clear",
+			expected: []string{"deleted Richard_Roe 34 <nil>", "deleted Reyna_Roe 45 <nil>", "deleted Ruth_Roe 60 <nil>", "deleted Tom_Poe 70 <nil>", "deleted Ann_Poe 21 <nil>"},
+		},
+	}
+
+	for i, tr := range testData {
+		events = events[:0]
+		err = tr.mutate()
+		if tr.operation != "" && err != nil {
+			t.Fatalf("mutation %d: error is: %s", i, err)
+		}
+
+		if tr.operation == "" {
+			if len(events) != 0 {
+				t.Fatalf("mutation %d: expected no events, got %v", i, events)
+			}
+
+			continue
+		}
+
+		if len(events) != 1 || events[0].Operation != tr.operation || events[0].Version != df.Version() {
+			t.Fatalf("mutation %d: expected one %s event for version %d, got %v", i, tr.operation, df.Version(), events)
+		}
+
+		changes := events[0].Changes
+		if len(changes) != len(tr.expected) {
+			t.Fatalf("mutation %d: expected %v, got %v", i, tr.expected, changes)
+		}
+
+		for j, expected := range tr.expected {
+			before, after := interface{}(nil), interface{}(nil)
+			if changes[j].Before != nil {
+				before = changes[j].Before["age"]
+			}
+
+			if changes[j].After != nil {
+				after = changes[j].After["age"]
+			}
+
+			got := fmt.Sprintf("%s %s %v %v", changes[j].Type, changes[j].Key, before, after)
+			if got != expected {
+				t.Fatalf("mutation %d, change %d: expected %s, got %s", i, j, expected, got)
+			}
+		}
+	}
+
+	unsubscribe()
+	events = events[:0]
+
+	err = df.Insert(dataArray)
+	if err != nil {
+		t.Fatalf("insert error is: %s", err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("expected no events after unsubscribing, got %v", events)
+	}
+}
+
+// SubscribeChan should send the events to a buffered channel, which is closed when the subscription ends
+func TestDataframe_SubscribeChan(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	events, unsubscribe := df.SubscribeChan(2)
+
+	err = df.Update(df.Col("age").GreaterThan(40), map[string]interface{}{"age": 40})
+	if err != nil {
+		t.Fatalf("update error is: %s", err)
+	}
+
+	err = df.Delete(df.Col("age").LessThan(20))
+	if err != nil {
+		t.Fatalf("delete error is: %s", err)
+	}
+
+	unsubscribe()
+	unsubscribe()
+
+	operations := []string{}
+	for event := range events {
+		operations = append(operations, event.Operation)
+	}
+
+	if len(operations) != 2 || operations[0] != "update" || operations[1] != "delete" {
+		t.Fatalf("expected update and delete events, got %v", operations)
+	}
+}
+
+// SyncDataframe subscribers should only receive the changes of writes that are published
+func TestSyncDataframe_Subscribe(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	s := NewSyncDataframe(df)
+	events, unsubscribe := s.SubscribeChan(10)
+
+	err = s.Write(func(df *Dataframe) error {
+		err := df.Delete(df.Col("age").GreaterThan(0))
+		if err != nil {
+			return err
+		}
+
+		return fmt.Errorf("failed after deleting")
+	})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	err = s.Insert([]map[string]interface{}{{"first name": "Tom", "last name": "Poe", "age": 70}})
+	if err != nil {
+		t.Fatalf("insert error is: %s", err)
+	}
+
+	err = s.Delete(Col("first name").Eq("Tom"))
+	if err != nil {
+		t.Fatalf("delete error is: %s", err)
+	}
+
+	unsubscribe()
+
+	expected := []string{"insert inserted Tom_Poe", "delete deleted Tom_Poe"}
+	got := []string{}
+	for event := range events {
+		for _, change := range event.Changes {
+			got = append(got, fmt.Sprintf("%s %s %s", event.Operation, change.Type, change.Key))
+		}
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
@@ -1,7 +1,13 @@
 package types
 
 import (
+	"fmt"
+	"math"
+	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -11,210 +17,628 @@ const (
 	ObjectType
 	BooleanType
 	ArrayType
+	DateTimeType
 )
 
 
 type Datatype int
 
+// Returns the name of the Datatype
+func (d Datatype) String() string {
+	switch d {
+	case IntType:
+		return "IntType"
+	case FloatType:
+		return "FloatType"
+	case StringType:
+		return "StringType"
+	case ObjectType:
+		return "ObjectType"
+	case BooleanType:
+		return "BooleanType"
+	case ArrayType:
+		return "ArrayType"
+	case DateTimeType:
+		return "DateTimeType"
+	default:
+		return fmt.Sprintf("Datatype(%d)", int(d))
+	}
+}
+
+// Error describing an item that could not be cast to a given Datatype
+type CastError struct {
+	Row int
+	Value interface{}
+	Dtype Datatype
+	Err error
+}
 
+func (e *CastError) Error() string {
+	return fmt.Sprintf("row %d: cannot cast %v (%T) to %v: %s", e.Row, e.Value, e.Value, e.Dtype, e.Err)
+}
+
+// List of all the cast errors met when casting a column
+type CastErrors []*CastError
+
+func (e CastErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d cast error(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// Columns store their items in contiguous slices whose type is chosen from their Dtype.
+// IntType, FloatType, StringType, BooleanType and DateTimeType columns keep a validity bitmap
+// to mark the rows that are not nil.
+// ObjectType and ArrayType columns keep the boxed values (nil included) in an []interface{}.
+// IntType items are stored as int64 but are returned as int.
+// A column created without a Dtype infers it from the first value that is not nil
 type Column struct {
 	Name string
-	items orderedMapType
-	Dtype Datatype
+	dtype Datatype
+	hasDtype bool
+	intItems []int64
+	floatItems []float64
+	stringItems []string
+	boolItems []bool
+	timeItems []time.Time
+	objectItems []interface{}
+	validity bitmap
+	// the number of goroutines that share the work of the predicates, set by the dataframe
+	parallelism int
+	// whether the items are shared with a version or a view of the dataframe, so they must be copied before they change
+	isShared bool
+}
+
+// Creates a new empty column whose storage is chosen basing on the dtype
+func newColumn(name string, dtype Datatype) *Column {
+	return &Column{Name: name, dtype: dtype, hasDtype: true}
+}
+
+// Creates a new empty column whose Dtype will be inferred from the first value inserted that is not nil
+func newUntypedColumn(name string) *Column {
+	return &Column{Name: name, dtype: ObjectType}
+}
+
+// Returns the Datatype of the column, which decides the slice its items are stored in.
+// It can only be changed with Cast or ParseDateTime, or by values that do not fit the column
+func (c *Column) Dtype() Datatype {
+	return c.dtype
+}
+
+// Casts the items of the column to the given Datatype, converting them where possible.
+// If any item cannot be converted, the column is left unchanged and a CastErrors error listing each row is returned
+func (c *Column) Cast(dtype Datatype) error {
+	return c.castWith(dtype, func(value interface{}) (interface{}, error) {
+		return coerceValue(value, dtype)
+	})
+}
+
+// Casts the items of the column to DateTimeType, parsing strings using the given layout e.g. time.RFC3339 or "2006-01-02".
+// If any item cannot be converted, the column is left unchanged and a CastErrors error listing each row is returned
+func (c *Column) ParseDateTime(layout string) error {
+	return c.castWith(DateTimeType, func(value interface{}) (interface{}, error) {
+		if v, ok := value.(string); ok {
+			return time.Parse(layout, strings.TrimSpace(v))
+		}
+
+		return coerceValue(value, DateTimeType)
+	})
+}
+
+// Casts the items of the column to the given Datatype using the convert function
+func (c *Column) castWith(dtype Datatype, convert func(value interface{}) (interface{}, error)) error {
+	items := c.Items()
+	converted := newColumn(c.Name, dtype)
+	converted.parallelism = c.parallelism
+	converted.grow(len(items))
+	errs := CastErrors{}
+
+	for i, item := range items {
+		value, err := convert(item)
+		if err != nil {
+			errs = append(errs, &CastError{Row: i, Value: item, Dtype: dtype, Err: err})
+			continue
+		}
+
+		converted.set(i, value)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	*c = *converted
+	return nil
 }
 
 // Returns a list of Items
 func (c *Column) Items() []interface{} {
-	return c.items.ToSlice()
+	items := make([]interface{}, c.Len())
+
+	runInChunks(c.parallelism, len(items), func(start int, end int) error {
+		for i := start; i < end; i++ {
+			items[i] = c.get(i)
+		}
+
+		return nil
+	})
+
+	return items
 }
 
+// Returns the number of items in the column
+func (c *Column) Len() int {
+	switch c.dtype {
+	case IntType:
+		return len(c.intItems)
+	case FloatType:
+		return len(c.floatItems)
+	case StringType:
+		return len(c.stringItems)
+	case BooleanType:
+		return len(c.boolItems)
+	case DateTimeType:
+		return len(c.timeItems)
+	default:
+		return len(c.objectItems)
+	}
+}
+
+// Returns the item at the given index, or nil if the index is beyond the length of the column
+func (c *Column) get(index int) interface{} {
+	if index >= c.Len() {
+		return nil
+	}
+
+	if c.dtype == ObjectType || c.dtype == ArrayType {
+		return c.objectItems[index]
+	}
+
+	if !c.validity.get(index) {
+		return nil
+	}
+
+	switch c.dtype {
+	case IntType:
+		return int(c.intItems[index])
+	case FloatType:
+		return c.floatItems[index]
+	case StringType:
+		return c.stringItems[index]
+	case BooleanType:
+		return c.boolItems[index]
+	case DateTimeType:
+		return c.timeItems[index]
+	}
+
+	return nil
+}
 
 // Inserts a given value at the given index.
-// If the index is beyond the length of keys,
-// it fills the gap in both Items and keys with nil and "" respectively
+// If the index is beyond the length of the column, it fills the gap with nil.
+// If the column has no Dtype yet, it is inferred from the value.
+// A float inserted in an IntType column changes the column to FloatType.
+// Any other value that cannot be held by the column's typed storage changes the column to ObjectType
 func (c *Column) insert(index int, value interface{}) {
-	nextIndex := len(c.items)
+	if !c.hasDtype && value != nil {
+		// all items are nil, so the cast cannot fail
+		c.Cast(inferDatatype(value))
+	}
+
+	count := c.Len()
+
+	if c.isShared {
+		// even appending may set a flag in the last word of the shared validity bitmap, so any change needs a copy.
+		// Appending to the clipped slices would copy the items anyway
+		*c = *c.copy()
+	}
+
+	if count <= index {
+		c.grow(index + 1 - count)
+	}
+
+	if c.set(index, value) {
+		return
+	}
+
+	if _, isFloat := toExactFloat64(value); isFloat && c.dtype == IntType {
+		// ints are cast to floats losslessly
+		c.Cast(FloatType)
+		c.set(index, value)
+		return
+	}
+
+	c.toObjects()
+	c.objectItems[index] = value
+}
+
+// Checks whether the value can be held by the column without changing its Dtype
+func (c *Column) fits(value interface{}) bool {
+	if value == nil || !c.hasDtype {
+		return true
+	}
+
+	switch c.dtype {
+	case IntType:
+		_, ok := toInt64(value)
+		return ok
+	case FloatType:
+		_, ok := toFloat64(value)
+		return ok
+	case StringType:
+		_, ok := value.(string)
+		return ok
+	case BooleanType:
+		_, ok := value.(bool)
+		return ok
+	case DateTimeType:
+		_, ok := value.(time.Time)
+		return ok
+	case ArrayType:
+		return isArray(value)
+	default:
+		return true
+	}
+}
 
-	if nextIndex <= index {
-		for i := nextIndex; i <= index; i++ {
-			// FIXME: concurrency possible
-			c.items[i] = nil		
+// Appends n nil values at the end of the column
+func (c *Column) grow(n int) {
+	switch c.dtype {
+	case IntType:
+		c.intItems = append(c.intItems, make([]int64, n)...)
+	case FloatType:
+		c.floatItems = append(c.floatItems, make([]float64, n)...)
+	case StringType:
+		c.stringItems = append(c.stringItems, make([]string, n)...)
+	case BooleanType:
+		c.boolItems = append(c.boolItems, make([]bool, n)...)
+	case DateTimeType:
+		c.timeItems = append(c.timeItems, make([]time.Time, n)...)
+	default:
+		c.objectItems = append(c.objectItems, make([]interface{}, n)...)
+	}
+}
+
+// Sets the value at an existing index in the typed storage. It returns false if the value does not fit the storage
+func (c *Column) set(index int, value interface{}) bool {
+	switch c.dtype {
+	case IntType, FloatType, StringType, BooleanType, DateTimeType:
+		if value == nil {
+			c.validity.set(index, false)
+			return true
 		}
+	case ArrayType:
+		if value != nil && !isArray(value) {
+			return false
+		}
+
+		c.objectItems[index] = value
+		return true
+	default:
+		c.objectItems[index] = value
+		return true
 	}
 
-	c.items[index] = value
+	isSet := false
+
+	switch c.dtype {
+	case IntType:
+		var v int64
+		if v, isSet = toInt64(value); isSet {
+			c.intItems[index] = v
+		}
+	case FloatType:
+		var v float64
+		if v, isSet = toFloat64(value); isSet {
+			c.floatItems[index] = v
+		}
+	case StringType:
+		var v string
+		if v, isSet = value.(string); isSet {
+			c.stringItems[index] = v
+		}
+	case BooleanType:
+		var v bool
+		if v, isSet = value.(bool); isSet {
+			c.boolItems[index] = v
+		}
+	case DateTimeType:
+		var v time.Time
+		if v, isSet = value.(time.Time); isSet {
+			c.timeItems[index] = v
+		}
+	}
+
+	if isSet {
+		c.validity.set(index, true)
+	}
+
+	return isSet
 }
 
-// Deletes many indices at once
-func (c *Column) deleteMany(indices []int)  {
-	for _, i := range indices {
-		// FIXME: concurrency possible
-		delete(c.items, i)
-	}	
+// Reorders the column such that the item at index i is the item that was at index indices[i].
+// Items not referenced in indices are dropped
+func (c *Column) take(indices []int) {
+	// the items are all copied to new slices, so they are no longer shared with any version
+	c.isShared = false
+
+	switch c.dtype {
+	case IntType:
+		items := make([]int64, len(indices))
+		for i, index := range indices {
+			items[i] = c.intItems[index]
+		}
+		c.intItems = items
+	case FloatType:
+		items := make([]float64, len(indices))
+		for i, index := range indices {
+			items[i] = c.floatItems[index]
+		}
+		c.floatItems = items
+	case StringType:
+		items := make([]string, len(indices))
+		for i, index := range indices {
+			items[i] = c.stringItems[index]
+		}
+		c.stringItems = items
+	case BooleanType:
+		items := make([]bool, len(indices))
+		for i, index := range indices {
+			items[i] = c.boolItems[index]
+		}
+		c.boolItems = items
+	case DateTimeType:
+		items := make([]time.Time, len(indices))
+		for i, index := range indices {
+			items[i] = c.timeItems[index]
+		}
+		c.timeItems = items
+	default:
+		items := make([]interface{}, len(indices))
+		for i, index := range indices {
+			items[i] = c.objectItems[index]
+		}
+		c.objectItems = items
+		return
+	}
+
+	c.validity = c.validity.take(indices)
 }
 
-// Returns an array of booleans corresponding in position to each item,
-// true if item is greater than operand or else false
-// The operand can reference a constant, or a Col
-func (c *Column) GreaterThan(operand float64) filterType {
-	count := len(c.items)
-	flags := make(filterType, count)
-
-	for i, v := range c.items {
-		// FIXME: concurrency possible
-		switch v := v.(type) {
-		case int:
-			flags[i] = float64(v) > operand
-		case int8:
-			flags[i] = float64(v) > operand
-		case int16:
-			flags[i] = float64(v) > operand
-		case int32:
-			flags[i] = float64(v) > operand
-		case int64:
-			flags[i] = float64(v) > operand
-		case float32:
-			flags[i] = float64(v) > operand
-		case float64:
-			flags[i] = v > operand
-		default:
-			flags[i] = false
+// Drops the items from position n onwards, keeping the Dtype of the column
+func (c *Column) truncate(n int) {
+	if n >= c.Len() {
+		return
+	}
+
+	switch c.dtype {
+	case IntType:
+		c.intItems = c.intItems[:n]
+	case FloatType:
+		c.floatItems = c.floatItems[:n]
+	case StringType:
+		c.stringItems = c.stringItems[:n]
+	case BooleanType:
+		c.boolItems = c.boolItems[:n]
+	case DateTimeType:
+		c.timeItems = c.timeItems[:n]
+	default:
+		c.objectItems = c.objectItems[:n]
+	}
+
+	c.validity = c.validity.truncate(n)
+}
+
+// Returns a new column with the given name whose item at index i is the item at rows[i] of this column,
+// or nil where rows[i] is negative
+func (c *Column) gather(name string, rows []int) *Column {
+	col := &Column{Name: name, dtype: c.dtype, hasDtype: c.hasDtype, parallelism: c.parallelism}
+	col.grow(len(rows))
+
+	// the rows are set one after the other as neighbouring rows share words of the validity bitmap
+	for i, row := range rows {
+		if row >= 0 {
+			col.set(i, c.get(row))
 		}
 	}
 
-	return flags
+	return col
+}
+
+// Returns a copy of the column that shares no storage with this column
+func (c *Column) copy() *Column {
+	return &Column{
+		Name: c.Name,
+		dtype: c.dtype,
+		hasDtype: c.hasDtype,
+		intItems: append([]int64(nil), c.intItems...),
+		floatItems: append([]float64(nil), c.floatItems...),
+		stringItems: append([]string(nil), c.stringItems...),
+		boolItems: append([]bool(nil), c.boolItems...),
+		timeItems: append([]time.Time(nil), c.timeItems...),
+		objectItems: append([]interface{}(nil), c.objectItems...),
+		validity: c.validity.copy(),
+		parallelism: c.parallelism,
+	}
+}
+
+// Moves the items of the column into the ObjectType storage
+func (c *Column) toObjects() {
+	if c.dtype != ObjectType && c.dtype != ArrayType {
+		c.objectItems = c.Items()
+		c.intItems = nil
+		c.floatItems = nil
+		c.stringItems = nil
+		c.boolItems = nil
+		c.timeItems = nil
+		c.validity = nil
+	}
+
+	c.dtype = ObjectType
+	c.hasDtype = true
 }
 
 // Returns an array of booleans corresponding in position to each item,
-// true if item is greater than or equal to the operand or else false
+// true if item is greater than operand or else false
 // The operand can reference a constant, or a Col
-func (c *Column) GreaterOrEquals(operand float64) filterType {
-	count := len(c.items)
-	flags := make(filterType, count)
-
-	for i, v := range c.items {
-		// FIXME: concurrency possible
-		switch v := v.(type) {
-		case int:
-			flags[i] = float64(v) >= operand
-		case int8:
-			flags[i] = float64(v) >= operand
-		case int16:
-			flags[i] = float64(v) >= operand
-		case int32:
-			flags[i] = float64(v) >= operand
-		case int64:
-			flags[i] = float64(v) >= operand
-		case float32:
-			flags[i] = float64(v) >= operand
-		case float64:
-			flags[i] = v >= operand
-		default:
-			flags[i] = false
-		}
-	}
+func (c *Column) GreaterThan(operand interface{}) filterType {
+	return c.compareNumbersWith(operand, func(v float64, o float64) bool { return v > o })
+}
 
-	return flags
+// Returns an array of booleans corresponding in position to each item,
+// true if item is greater than or equal to the operand or else false
+// The operand can reference a constant, or a Col
+func (c *Column) GreaterOrEquals(operand interface{}) filterType {
+	return c.compareNumbersWith(operand, func(v float64, o float64) bool { return v >= o })
 }
 
 // Returns an array of booleans corresponding in position to each item,
 // true if item is less than operand or else false
 // The operand can reference a constant, or a Col
-func (c *Column) LessThan(operand float64) filterType {
-	count := len(c.items)
-	flags := make(filterType, count)
-
-	for i, v := range c.items {
-		// FIXME: concurrency possible
-		switch v := v.(type) {
-		case int:
-			flags[i] = float64(v) < operand
-		case int8:
-			flags[i] = float64(v) < operand
-		case int16:
-			flags[i] = float64(v) < operand
-		case int32:
-			flags[i] = float64(v) < operand
-		case int64:
-			flags[i] = float64(v) < operand
-		case float32:
-			flags[i] = float64(v) < operand
-		case float64:
-			flags[i] = v < operand
-		default:
-			flags[i] = false
-		}
-	}
-
-	return flags
+func (c *Column) LessThan(operand interface{}) filterType {
+	return c.compareNumbersWith(operand, func(v float64, o float64) bool { return v < o })
 }
 
 // Returns an array of booleans corresponding in position to each item,
 // true if item is less than or equal to the operand or else false
 // The operand can reference a constant, or a Col
-func (c *Column) LessOrEquals(operand float64) filterType {
-	count := len(c.items)
-	flags := make(filterType, count)
-
-	for i, v := range c.items {
-		// FIXME: concurrency possible
-		switch v := v.(type) {
-		case int:
-			flags[i] = float64(v) <= operand
-		case int8:
-			flags[i] = float64(v) <= operand
-		case int16:
-			flags[i] = float64(v) <= operand
-		case int32:
-			flags[i] = float64(v) <= operand
-		case int64:
-			flags[i] = float64(v) <= operand
-		case float32:
-			flags[i] = float64(v) <= operand
-		case float64:
-			flags[i] = v <= operand
-		default:
-			flags[i] = false
-		}
-	}
-
-	return flags
+func (c *Column) LessOrEquals(operand interface{}) filterType {
+	return c.compareNumbersWith(operand, func(v float64, o float64) bool { return v <= o })
 }
 
 // Returns an array of booleans corresponding in position to each item,
 // true if item is equal to operand or else false
-// The operand can reference a constant, or a Col
+// The operand can reference a constant, or a Col. Items are equal to those of a Col if they are both nil,
+// or if they are numbers of the same value, even if one is an int and the other a float
 func (c *Column) Equals(operand interface{}) filterType {
-	count := len(c.items)
-	flags := make(filterType, count)
+	if other, isCol := operand.(*Column); isCol {
+		return c.getFlags(func(flags filterType, start int, end int) {
+			for i := start; i < end; i++ {
+				flags[i] = areEqual(c.get(i), other.get(i))
+			}
+		})
+	}
 
-	for i, v := range c.items {
-		// FIXME: concurrency possible
-		flags[i] = v == operand
+	if str, isStr := operand.(string); isStr && c.dtype == StringType {
+		return c.getFlags(func(flags filterType, start int, end int) {
+			for i := start; i < end; i++ {
+				flags[i] = c.validity.get(i) && c.stringItems[i] == str
+			}
+		})
 	}
 
-	return flags
+	return c.getFlags(func(flags filterType, start int, end int) {
+		for i := start; i < end; i++ {
+			flags[i] = c.get(i) == operand
+		}
+	})
 }
 
 // Returns an array of booleans corresponding in position to each item,
-// true if item is like the regex expression or else false
-func (c *Column) IsLike(pattern *regexp.Regexp) filterType  {
-	count := len(c.items)
-	flags := make(filterType, count)
+// true if item is a string equal to the operand, ignoring case, or else false
+func (c *Column) EqualsIgnoreCase(operand string) filterType {
+	return c.matchStrings(func(v string) bool { return strings.EqualFold(v, operand) })
+}
 
-	for i, v := range c.items {
-		// FIXME: concurrency possible
-		switch v := v.(type) {
-		case string:
-			flags[i] = pattern.MatchString(v)
-		case []byte:
-			flags[i] = pattern.Match(v)
-		default:
-			flags[i] = false
-		}		
+// Returns an array of booleans corresponding in position to each item,
+// true if item is equal to any of the values or else false. Numbers are equal if their values are,
+// and integers are compared exactly, even beyond 2^53
+func (c *Column) IsIn(values ...interface{}) filterType {
+	set := make(map[interface{}]struct{}, len(values))
+	hasNil := false
+
+	for _, value := range values {
+		if value == nil {
+			hasNil = true
+		} else {
+			set[getDistinctKey(value)] = struct{}{}
+		}
 	}
 
-	return flags
+	return c.getFlags(func(flags filterType, start int, end int) {
+		for i := start; i < end; i++ {
+			v := c.get(i)
+			if v == nil {
+				flags[i] = hasNil
+			} else {
+				_, flags[i] = set[getDistinctKey(v)]
+			}
+		}
+	})
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if item is between lo and hi, both included, or else false.
+// Numbers, strings and date-times can be compared, and lo and hi can reference constants, or Cols
+func (c *Column) Between(lo interface{}, hi interface{}) filterType {
+	getLo := getOperandGetter(lo)
+	getHi := getOperandGetter(hi)
+
+	return c.getFlags(func(flags filterType, start int, end int) {
+		for i := start; i < end; i++ {
+			v, low, high := c.get(i), getLo(i), getHi(i)
+			flags[i] = v != nil && low != nil && high != nil && compareValues(low, v) <= 0 && compareValues(v, high) <= 0
+		}
+	})
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if item is nil or else false
+func (c *Column) IsNull() filterType {
+	return c.getFlags(func(flags filterType, start int, end int) {
+		for i := start; i < end; i++ {
+			flags[i] = c.get(i) == nil
+		}
+	})
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if item is not nil or else false
+func (c *Column) NotNull() filterType {
+	return NOT(c.IsNull())
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if item is a string containing the substring or else false
+func (c *Column) Contains(substring string) filterType {
+	return c.matchStrings(func(v string) bool { return strings.Contains(v, substring) })
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if item is a string starting with the prefix or else false
+func (c *Column) StartsWith(prefix string) filterType {
+	return c.matchStrings(func(v string) bool { return strings.HasPrefix(v, prefix) })
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if item is a string ending with the suffix or else false
+func (c *Column) EndsWith(suffix string) filterType {
+	return c.matchStrings(func(v string) bool { return strings.HasSuffix(v, suffix) })
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if item is like the regex expression or else false
+func (c *Column) IsLike(pattern *regexp.Regexp) filterType  {
+	return c.getFlags(func(flags filterType, start int, end int) {
+		switch c.dtype {
+		case StringType:
+			for i := start; i < end; i++ {
+				flags[i] = c.validity.get(i) && pattern.MatchString(c.stringItems[i])
+			}
+		case ObjectType, ArrayType:
+			for i := start; i < end; i++ {
+				switch v := c.objectItems[i].(type) {
+				case string:
+					flags[i] = pattern.MatchString(v)
+				case []byte:
+					flags[i] = pattern.Match(v)
+				}
+			}
+		}
+	})
 }
 
 // Returns transformer method specific to this column to transform its values from one thing to another
@@ -224,12 +648,305 @@ func (c *Column) Tx(op rowWiseFunc) transformation {
 }
 
 // Returns an aggregation function specific to this column to
-// merge its values into a single value. It works when GroupBy is used
+// merge its values into a single value. It works when GroupBy is used.
+// The result replaces the column unless given another name with As
 func (c *Column) Agg(aggFunc aggregateFunc) aggregation {
-	return aggregation{c.Name: aggFunc}
+	return aggregation{field: c.Name, name: c.Name, fn: aggFunc}
+}
+
+// Returns the window over which window functions are computed for this column.
+// The rows are split by PartitionBy and ordered by OrderBy options. The frame of each row stretches
+// from the start of its partition to the row itself, unless changed with Rows
+func (c *Column) Over(options ...windowOption) windowSpec {
+	spec := windowSpec{field: c.Name, preceding: UNBOUNDED, following: 0}
+	for _, opt := range options {
+		spec.partitionBy = append(spec.partitionBy, opt.partitionBy...)
+		spec.orderBy = append(spec.orderBy, opt.orderBy...)
+	}
+
+	return spec
+}
+
+// Returns the window expression that aggregates the values of each row and the window-1 rows before it,
+// in the order of the query's SortBy, as a new column named after this column and the aggregate e.g. "sales_rolling_sum".
+// The aggregate is nil for rows that have fewer than window non-nil values in their frame, unless changed with MinPeriods.
+// Built-in aggregates other than MEDIAN, PERCENTILE, MODE and COLLECT are updated as the frame slides, rather than
+// computed afresh for every row
+func (c *Column) Rolling(window int, aggFunc aggregateFunc) windowExpr {
+	spec := windowSpec{field: c.Name, preceding: window - 1, following: 0, minPeriods: window}
+	if window < 1 {
+		spec.err = fmt.Errorf("window error: a rolling window must have at least 1 row, got %d", window)
+	}
+
+	return spec.Apply(getFramedAggregate(getFramedName("rolling", aggFunc), aggFunc))
+}
+
+// Returns the window expression that aggregates the values of each row and all the rows before it,
+// in the order of the query's SortBy, as a new column named after this column and the aggregate e.g. "sales_expanding_max"
+func (c *Column) Expanding(aggFunc aggregateFunc) windowExpr {
+	spec := windowSpec{field: c.Name, preceding: UNBOUNDED, following: 0, minPeriods: 1}
+	return spec.Apply(getFramedAggregate(getFramedName("expanding", aggFunc), aggFunc))
 }
 
 // Returns a Sort Option that is attached to this column, for the given order
 func (c *Column) Order(option sortOrder) sortOption {
 	return sortOption{c.Name: option}
 }
+
+// Returns an array of booleans corresponding in position to each item,
+// true if the item and the operand, a constant or a Col, are numbers that pass the check, or else false
+func (c *Column) compareNumbersWith(operand interface{}, check func(float64, float64) bool) filterType {
+	other, isCol := operand.(*Column)
+	if !isCol {
+		number, ok := toFloat64(operand)
+		if !ok {
+			return make(filterType, c.Len())
+		}
+
+		return c.compareNumbers(func(v float64) bool { return check(v, number) })
+	}
+
+	return c.getFlags(func(flags filterType, start int, end int) {
+		for i := start; i < end; i++ {
+			v, isNumber := toFloat64(c.get(i))
+			o, isOtherNumber := toFloat64(other.get(i))
+			flags[i] = isNumber && isOtherNumber && check(v, o)
+		}
+	})
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if the item is a string that passes the check, or else false
+func (c *Column) matchStrings(check func(string) bool) filterType {
+	return c.getFlags(func(flags filterType, start int, end int) {
+		switch c.dtype {
+		case StringType:
+			for i := start; i < end; i++ {
+				flags[i] = c.validity.get(i) && check(c.stringItems[i])
+			}
+		case ObjectType, ArrayType:
+			for i := start; i < end; i++ {
+				if v, ok := c.objectItems[i].(string); ok {
+					flags[i] = check(v)
+				}
+			}
+		}
+	})
+}
+
+// Returns an array of booleans corresponding in position to each item,
+// true if the item is a number that passes the check, or else false
+func (c *Column) compareNumbers(check func(float64) bool) filterType {
+	return c.getFlags(func(flags filterType, start int, end int) {
+		switch c.dtype {
+		case IntType:
+			for i := start; i < end; i++ {
+				flags[i] = c.validity.get(i) && check(float64(c.intItems[i]))
+			}
+		case FloatType:
+			for i := start; i < end; i++ {
+				flags[i] = c.validity.get(i) && check(c.floatItems[i])
+			}
+		case ObjectType, ArrayType:
+			for i := start; i < end; i++ {
+				if v, ok := toFloat64(c.objectItems[i]); ok {
+					flags[i] = check(v)
+				}
+			}
+		}
+	})
+}
+
+// Returns an array of booleans corresponding in position to each item, filled by fill
+// for chunks of the items that are shared among the goroutines of the column
+func (c *Column) getFlags(fill func(flags filterType, start int, end int)) filterType {
+	flags := make(filterType, c.Len())
+
+	runInChunks(c.parallelism, len(flags), func(start int, end int) error {
+		fill(flags, start, end)
+		return nil
+	})
+
+	return flags
+}
+
+/*
+* Helpers
+*/
+
+// Returns a function giving the value of the operand for a given row: the item of a Col, or else the constant
+func getOperandGetter(operand interface{}) func(int) interface{} {
+	if col, ok := operand.(*Column); ok {
+		return col.get
+	}
+
+	return func(int) interface{} { return operand }
+}
+
+// Checks whether two values are equal: both nil, numbers of the same value, or equal values of any other type.
+// Integers are compared exactly, even beyond 2^53
+func areEqual(first interface{}, second interface{}) bool {
+	if first == nil || second == nil {
+		return first == second
+	}
+
+	return getDistinctKey(first) == getDistinctKey(second)
+}
+
+// Converts a value of any integer type to int64, returning false if it is not an integer or it overflows int64
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint:
+		return int64(v), v <= math.MaxInt64
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	}
+
+	return 0, false
+}
+
+// Converts a float32 or a float64 to float64, returning false if it is any other type
+func toExactFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
+
+// Converts a value of any integer or float type to float64, returning false if it is not a number
+func toFloat64(value interface{}) (float64, bool) {
+	if v, ok := toExactFloat64(value); ok {
+		return v, true
+	}
+
+	if v, ok := toInt64(value); ok {
+		return float64(v), true
+	}
+
+	switch v := value.(type) {
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+// Checks whether the value is a slice or an array
+func isArray(value interface{}) bool {
+	kind := reflect.TypeOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// Infers the Datatype of the column that would hold the value
+func inferDatatype(value interface{}) Datatype {
+	if value == nil {
+		return ObjectType
+	}
+
+	if _, ok := value.(time.Time); ok {
+		return DateTimeType
+	}
+
+	return getDatatypeOfKind(reflect.TypeOf(value).Kind())
+}
+
+// Converts the value to the canonical type held by columns of the given Datatype,
+// parsing strings and formatting numbers where necessary. nil is returned as is
+func coerceValue(value interface{}, dtype Datatype) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch dtype {
+	case IntType:
+		if v, ok := toInt64(value); ok {
+			return int(v), nil
+		}
+
+		if v, ok := toExactFloat64(value); ok {
+			if v != math.Trunc(v) || v < math.MinInt64 || v > math.MaxInt64 {
+				return nil, fmt.Errorf("%v is not a whole number within range", v)
+			}
+			return int(v), nil
+		}
+
+		if v, ok := value.(string); ok {
+			i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			return int(i), err
+		}
+	case FloatType:
+		if v, ok := toFloat64(value); ok {
+			return v, nil
+		}
+
+		if v, ok := value.(string); ok {
+			return strconv.ParseFloat(strings.TrimSpace(v), 64)
+		}
+	case StringType:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		case float32:
+			return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		}
+
+		if v, ok := toInt64(value); ok {
+			return strconv.FormatInt(v, 10), nil
+		}
+	case BooleanType:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+
+		if v, ok := value.(string); ok {
+			return strconv.ParseBool(strings.TrimSpace(v))
+		}
+
+		if v, ok := toInt64(value); ok && (v == 0 || v == 1) {
+			return v == 1, nil
+		}
+	case DateTimeType:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			return time.Parse(time.RFC3339Nano, strings.TrimSpace(v))
+		}
+	case ArrayType:
+		if isArray(value) {
+			return value, nil
+		}
+	default:
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("unsupported conversion")
+}
@@ -1,18 +1,309 @@
 package types
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/learn-along/learn-go/projects/dataframe/utils"
 )
 
-// insert for columns should fill any gaps in keys and Items with "", nil respectively
+// insert for columns should fill any gaps in the items with nil
 func TestColumn_insert(t *testing.T)  {
-	col := Column{Name: "hi", Dtype: StringType, items: map[int]interface{}{0: "hi", 1: "wow"}}
-	col.insert(4, "yeah")
-	expectedItems := []interface{}{"hi", "wow", nil, nil, "yeah"}
+	for _, dtype := range []Datatype{StringType, ObjectType} {
+		col := newColumn("hi", dtype)
+		col.insert(0, "hi")
+		col.insert(1, "wow")
+		col.insert(4, "yeah")
+		expectedItems := []interface{}{"hi", "wow", nil, nil, "yeah"}
 
-	if !utils.AreSliceEqual(expectedItems, col.Items()) {
-		t.Fatalf("items expected: %v, got %v", expectedItems, col.Items())
+		if !utils.AreSliceEqual(expectedItems, col.Items()) {
+			t.Fatalf("dtype %v items expected: %v, got %v", dtype, expectedItems, col.Items())
+		}
+
+		if col.Dtype() != dtype {
+			t.Fatalf("dtype expected: %v, got %v", dtype, col.Dtype())
+		}
+	}
+}
+
+// insert for typed columns should store the values in the typed storage, falling back to
+// ObjectType storage when a value does not fit
+func TestColumn_insertTyped(t *testing.T)  {
+	type testRecord struct {
+		dtype Datatype;
+		values []interface{};
+		expected []interface{};
+		expectedDtype Datatype;
+	}
+
+	testData := []testRecord{
+		{
+			dtype: IntType,
+			values: []interface{}{1, int8(2), nil, int64(4)},
+			expected: []interface{}{1, 2, nil, 4},
+			expectedDtype: IntType,
+		},
+		{
+			dtype: FloatType,
+			values: []interface{}{1.5, nil, float32(2.5)},
+			expected: []interface{}{1.5, nil, 2.5},
+			expectedDtype: FloatType,
+		},
+		{
+			dtype: BooleanType,
+			values: []interface{}{true, false, nil},
+			expected: []interface{}{true, false, nil},
+			expectedDtype: BooleanType,
+		},
+		{
+			dtype: IntType,
+			values: []interface{}{1, "two", nil, 3},
+			expected: []interface{}{1, "two", nil, 3},
+			expectedDtype: ObjectType,
+		},
+	}
+
+	for _, tr := range testData {
+		col := newColumn("hi", tr.dtype)
+		for i, v := range tr.values {
+			col.insert(i, v)
+		}
+
+		if !utils.AreSliceEqual(tr.expected, col.Items()) {
+			t.Fatalf("items expected: %v, got %v", tr.expected, col.Items())
+		}
+
+		if col.Dtype() != tr.expectedDtype {
+			t.Fatalf("dtype expected: %v, got %v", tr.expectedDtype, col.Dtype())
+		}
+	}
+}
+
+// take should reorder the column such that item at i is the item that was at indices[i], dropping the rest
+func TestColumn_take(t *testing.T)  {
+	for _, dtype := range []Datatype{IntType, ObjectType} {
+		col := newColumn("hi", dtype)
+		for i, v := range []interface{}{10, nil, 30, 40} {
+			col.insert(i, v)
+		}
+
+		col.take([]int{3, 1, 0})
+		expectedItems := []interface{}{40, nil, 10}
+
+		if !utils.AreSliceEqual(expectedItems, col.Items()) {
+			t.Fatalf("dtype %v items expected: %v, got %v", dtype, expectedItems, col.Items())
+		}
+	}
+}
+
+// The predicates should give the same results whatever the storage of the column
+func TestColumn_predicates(t *testing.T)  {
+	values := []interface{}{30, nil, 19, 45}
+
+	for _, dtype := range []Datatype{IntType, ObjectType} {
+		col := newColumn("age", dtype)
+		for i, v := range values {
+			col.insert(i, v)
+		}
+
+		type testRecord struct {
+			got filterType;
+			expected filterType;
+		}
+
+		testData := []testRecord{
+			{got: col.GreaterThan(30), expected: filterType{false, false, false, true}},
+			{got: col.GreaterOrEquals(30), expected: filterType{true, false, false, true}},
+			{got: col.LessThan(30), expected: filterType{false, false, true, false}},
+			{got: col.LessOrEquals(30), expected: filterType{true, false, true, false}},
+			{got: col.Equals(19), expected: filterType{false, false, true, false}},
+			{got: col.Equals(nil), expected: filterType{false, true, false, false}},
+		}
+
+		for i, tr := range testData {
+			for j, expected := range tr.expected {
+				if tr.got[j] != expected {
+					t.Fatalf("dtype %v, predicate %d: expected %v, got %v", dtype, i, tr.expected, tr.got)
+				}
+			}
+		}
+	}
+
+	col := newColumn("name", StringType)
+	for i, v := range []interface{}{"John", nil, "Jane"} {
+		col.insert(i, v)
+	}
+
+	expected := filterType{true, false, true}
+	got := col.IsLike(regexp.MustCompile("^J"))
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+// The predicates should compare items to those of other columns, and check membership, ranges, nils and substrings
+func TestColumn_morePredicates(t *testing.T)  {
+	age := newUntypedColumn("age")
+	limit := newUntypedColumn("limit")
+	name := newUntypedColumn("name")
+
+	for i, v := range []interface{}{30, nil, 19, 45} {
+		age.insert(i, v)
+	}
+
+	for i, v := range []interface{}{30.0, 20.0, 20.0, nil} {
+		limit.insert(i, v)
+	}
+
+	for i, v := range []interface{}{"John", nil, "jane", "Ruth"} {
+		name.insert(i, v)
+	}
+
+	// ints beyond 2^53 cannot be told apart as float64s
+	big := newUntypedColumn("big")
+	bigger := newUntypedColumn("bigger")
+	for i, v := range []int64{1 << 53, 1 << 53 + 1, 1 << 53 + 1, 3} {
+		big.insert(i, v)
+		bigger.insert(i, v + int64(i % 2))
+	}
+
+	type testRecord struct {
+		got filterType;
+		expected filterType;
+	}
+
+	testData := []testRecord{
+		{got: age.GreaterThan(limit), expected: filterType{false, false, false, false}},
+		{got: age.GreaterOrEquals(limit), expected: filterType{true, false, false, false}},
+		{got: age.LessThan(limit), expected: filterType{false, false, true, false}},
+		{got: age.LessOrEquals(30.5), expected: filterType{true, false, true, false}},
+		{got: age.GreaterThan("30"), expected: filterType{false, false, false, false}},
+		{got: age.Equals(limit), expected: filterType{true, false, false, false}},
+		{got: age.IsIn(19, 45.0, "John"), expected: filterType{false, false, true, true}},
+		{got: age.IsIn(nil), expected: filterType{false, true, false, false}},
+		{got: name.IsIn("John", "Jane"), expected: filterType{true, false, false, false}},
+		{got: big.IsIn(1 << 53 + 1), expected: filterType{false, true, true, false}},
+		{got: big.IsIn(float64(1 << 53), 3.0), expected: filterType{true, false, false, true}},
+		{got: big.Equals(bigger), expected: filterType{true, false, true, false}},
+		{got: age.Between(19, 30), expected: filterType{true, false, true, false}},
+		{got: age.Between(limit, 40), expected: filterType{true, false, false, false}},
+		{got: name.Between("J", "K"), expected: filterType{true, false, false, false}},
+		{got: age.IsNull(), expected: filterType{false, true, false, false}},
+		{got: limit.NotNull(), expected: filterType{true, true, true, false}},
+		{got: name.Contains("oh"), expected: filterType{true, false, false, false}},
+		{got: name.StartsWith("J"), expected: filterType{true, false, false, false}},
+		{got: name.EndsWith("e"), expected: filterType{false, false, true, false}},
+		{got: name.EqualsIgnoreCase("JANE"), expected: filterType{false, false, true, false}},
+		{got: age.Contains("3"), expected: filterType{false, false, false, false}},
+	}
+
+	for i, tr := range testData {
+		if len(tr.got) != len(tr.expected) {
+			t.Fatalf("predicate %d: expected %v, got %v", i, tr.expected, tr.got)
+		}
+
+		for j, expected := range tr.expected {
+			if tr.got[j] != expected {
+				t.Fatalf("predicate %d: expected %v, got %v", i, tr.expected, tr.got)
+			}
+		}
+	}
+}
+
+// insert on a column without a Dtype should infer the Dtype from the first value that is not nil,
+// and widen an IntType column to FloatType when a float is inserted
+func TestColumn_insertInfersDtype(t *testing.T)  {
+	type testRecord struct {
+		values []interface{};
+		expected []interface{};
+		expectedDtype Datatype;
+	}
+
+	testData := []testRecord{
+		{values: []interface{}{nil, 1, 2}, expected: []interface{}{nil, 1, 2}, expectedDtype: IntType},
+		{values: []interface{}{1, 2.5, nil}, expected: []interface{}{1.0, 2.5, nil}, expectedDtype: FloatType},
+		{values: []interface{}{"a", nil}, expected: []interface{}{"a", nil}, expectedDtype: StringType},
+		{values: []interface{}{true, 1}, expected: []interface{}{true, 1}, expectedDtype: ObjectType},
+		{values: []interface{}{nil, nil}, expected: []interface{}{nil, nil}, expectedDtype: ObjectType},
+	}
+
+	for _, tr := range testData {
+		col := newUntypedColumn("hi")
+		for i, v := range tr.values {
+			col.insert(i, v)
+		}
+
+		if !utils.AreSliceEqual(tr.expected, col.Items()) {
+			t.Fatalf("items expected: %v, got %v", tr.expected, col.Items())
+		}
+
+		if col.Dtype() != tr.expectedDtype {
+			t.Fatalf("dtype expected: %v, got %v", tr.expectedDtype, col.Dtype())
+		}
+	}
+
+	col := newUntypedColumn("tags")
+	col.insert(0, []string{"a"})
+	if col.Dtype() != ArrayType {
+		t.Fatalf("dtype expected: %v, got %v", ArrayType, col.Dtype())
+	}
+}
+
+// Cast should convert all the items to the given Datatype, or leave the column untouched
+// and report the rows that cannot be converted
+func TestColumn_Cast(t *testing.T)  {
+	type testRecord struct {
+		values []interface{};
+		dtype Datatype;
+		expected []interface{};
+		failedRows []int;
+	}
+
+	testData := []testRecord{
+		{values: []interface{}{"1", 2.0, nil, int8(4)}, dtype: IntType, expected: []interface{}{1, 2, nil, 4}},
+		{values: []interface{}{"1.5", 2, nil}, dtype: FloatType, expected: []interface{}{1.5, 2.0, nil}},
+		{values: []interface{}{1, 2.5, true, "x"}, dtype: StringType, expected: []interface{}{"1", "2.5", "true", "x"}},
+		{values: []interface{}{"true", 0, false}, dtype: BooleanType, expected: []interface{}{true, false, false}},
+		{values: []interface{}{"1", "one", 2.5, 3}, dtype: IntType, expected: []interface{}{"1", "one", 2.5, 3}, failedRows: []int{1, 2}},
+	}
+
+	for _, tr := range testData {
+		col := newColumn("hi", ObjectType)
+		for i, v := range tr.values {
+			col.insert(i, v)
+		}
+
+		err := col.Cast(tr.dtype)
+
+		if tr.failedRows == nil {
+			if err != nil {
+				t.Fatalf("cast error is: %s", err)
+			}
+
+			if col.Dtype() != tr.dtype {
+				t.Fatalf("dtype expected: %v, got %v", tr.dtype, col.Dtype())
+			}
+		} else {
+			errs, ok := err.(CastErrors)
+			if !ok || len(errs) != len(tr.failedRows) {
+				t.Fatalf("expected cast errors on rows %v, got %v", tr.failedRows, err)
+			}
+
+			for i, row := range tr.failedRows {
+				if errs[i].Row != row {
+					t.Fatalf("expected cast errors on rows %v, got %v", tr.failedRows, err)
+				}
+			}
+
+			if col.Dtype() != ObjectType {
+				t.Fatalf("dtype expected: %v, got %v", ObjectType, col.Dtype())
+			}
+		}
+
+		if !utils.AreSliceEqual(tr.expected, col.Items()) {
+			t.Fatalf("items expected: %v, got %v", tr.expected, col.Items())
+		}
 	}
-}
\ No newline at end of file
+}
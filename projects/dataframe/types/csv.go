@@ -0,0 +1,329 @@
+package types
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// quote only the fields that contain the delimiter, quotes or new lines
+	QUOTE_MINIMAL quotingMode = iota
+	// quote every field
+	QUOTE_ALL
+	// quote every field that does not hold a number or a boolean
+	QUOTE_NONNUMERIC
+)
+
+type quotingMode int
+
+// Options used when reading a Dataframe from CSV or writing it out as CSV
+type CSVOptions struct {
+	// The field delimiter. It defaults to ','
+	Delimiter rune
+	// If true, the first row is treated as data, not as the header row, when reading,
+	// and no header row is written when writing
+	NoHeader bool
+	// When reading without a header row, these are the names of the columns in order; they default to "0", "1", ...
+	// When writing, only these columns are written, in this order;
+	// they default to the primary key fields followed by the other columns in ascending order
+	Columns []string
+	// How fields are quoted when writing. It defaults to QUOTE_MINIMAL
+	Quoting quotingMode
+	// If true, a quote may appear in an unquoted field and a non-doubled quote may appear in a quoted field when reading
+	LazyQuotes bool
+	// The raw values that are read as nil. It defaults to the empty string.
+	// The first token is what is written for nil values
+	NullTokens []string
+	// If true, each column's Datatype is inferred from its values when reading, else all values are read as strings
+	InferTypes bool
+	// The Datatypes for given columns when reading. These take precedence over the inferred types
+	Dtypes map[string]Datatype
+	// The layout of DateTimeType values, used to parse them when reading and to format them when writing.
+	// It defaults to time.RFC3339
+	DateTimeLayout string
+	// The fields used to uniquely identify the records read
+	PrimaryFields []string
+}
+
+// Constructs a Dataframe from CSV data read from r and returns a pointer to it
+func FromCSV(r io.Reader, opts CSVOptions) (*Dataframe, error) {
+	reader := csv.NewReader(r)
+	reader.LazyQuotes = opts.LazyQuotes
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	header := opts.Columns
+	if !opts.NoHeader && len(rows) > 0 {
+		header = rows[0]
+		rows = rows[1:]
+	}
+
+	if header == nil && len(rows) > 0 {
+		header = make([]string, len(rows[0]))
+		for i := range header {
+			header[i] = strconv.Itoa(i)
+		}
+	}
+
+	if len(rows) > 0 && len(header) != len(rows[0]) {
+		return nil, fmt.Errorf("csv error: %d columns given for records of %d fields", len(header), len(rows[0]))
+	}
+
+	nullTokens := getNullTokenMap(opts.NullTokens)
+	layout := getDateTimeLayout(opts.DateTimeLayout)
+	dtypes := make([]Datatype, len(header))
+
+	for i, field := range header {
+		if dtype, ok := opts.Dtypes[field]; ok {
+			dtypes[i] = dtype
+		} else if opts.InferTypes {
+			dtypes[i] = inferCSVDatatype(rows, i, nullTokens, layout)
+		} else {
+			dtypes[i] = StringType
+		}
+	}
+
+	df := Dataframe{
+		pkFields: opts.PrimaryFields,
+		cols: make(map[string]*Column, len(header)),
+		index: map[interface{}]int{},
+	}
+
+	for i, field := range header {
+		df.cols[field] = newColumn(field, dtypes[i])
+	}
+
+	for line, row := range rows {
+		record := make(map[string]interface{}, len(header))
+
+		for i, field := range header {
+			if _, isNull := nullTokens[row[i]]; isNull {
+				record[field] = nil
+				continue
+			}
+
+			value, err := parseCSVValue(row[i], dtypes[i], layout)
+			if err != nil {
+				return nil, fmt.Errorf("csv error on record %d, field '%s': %s", line+1, field, err)
+			}
+
+			record[field] = value
+		}
+
+		err = df.insertRecord(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	df.normalizeCols(nil)
+	return &df, nil
+}
+
+// Writes the records of the dataframe to w as CSV
+func (d *Dataframe) ToCSV(w io.Writer, opts CSVOptions) error {
+	delimiter := ','
+	if opts.Delimiter != 0 {
+		delimiter = opts.Delimiter
+	}
+
+	nullToken := ""
+	if len(opts.NullTokens) > 0 {
+		nullToken = opts.NullTokens[0]
+	}
+
+	fields := opts.Columns
+	if fields == nil {
+		fields = d.orderedColumnNames()
+	}
+
+	writer := csvWriter{w: w, delimiter: delimiter, quoting: opts.Quoting, layout: getDateTimeLayout(opts.DateTimeLayout)}
+
+	if !opts.NoHeader {
+		header := make([]interface{}, len(fields))
+		for i, field := range fields {
+			header[i] = field
+		}
+
+		err := writer.writeRow(header, nullToken)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.iterRows(fields, func(row []interface{}) error {
+		return writer.writeRow(row, nullToken)
+	})
+}
+
+/*
+* Helpers
+*/
+
+// Writes rows of values as CSV, quoting fields basing on the quoting mode
+type csvWriter struct {
+	w io.Writer
+	delimiter rune
+	quoting quotingMode
+	layout string
+}
+
+// Writes a single row of values followed by a new line
+func (c *csvWriter) writeRow(row []interface{}, nullToken string) error {
+	var line strings.Builder
+
+	for i, value := range row {
+		if i > 0 {
+			line.WriteRune(c.delimiter)
+		}
+
+		if value == nil {
+			line.WriteString(c.quote(nullToken, false))
+			continue
+		}
+
+		line.WriteString(c.quote(formatCSVValue(value, c.layout), isNumericOrBool(value)))
+	}
+
+	line.WriteString("\n")
+	_, err := io.WriteString(c.w, line.String())
+	return err
+}
+
+// Quotes the field if the quoting mode requires it
+func (c *csvWriter) quote(field string, isNumeric bool) string {
+	shouldQuote := false
+
+	switch c.quoting {
+	case QUOTE_ALL:
+		shouldQuote = true
+	case QUOTE_NONNUMERIC:
+		shouldQuote = !isNumeric
+	}
+
+	if !shouldQuote {
+		shouldQuote = strings.ContainsRune(field, c.delimiter) ||
+			strings.ContainsAny(field, "\"\r\n") ||
+			strings.HasPrefix(field, " ") || strings.HasPrefix(field, "\t")
+	}
+
+	if !shouldQuote {
+		return field
+	}
+
+	return `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+}
+
+// Converts the null tokens into a map for quick lookups, defaulting to the empty string
+func getNullTokenMap(tokens []string) map[string]struct{} {
+	if tokens == nil {
+		tokens = []string{""}
+	}
+
+	_map := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		_map[token] = struct{}{}
+	}
+
+	return _map
+}
+
+// Returns the layout of DateTimeType values, defaulting to time.RFC3339
+func getDateTimeLayout(layout string) string {
+	if layout == "" {
+		return time.RFC3339
+	}
+
+	return layout
+}
+
+// Infers the Datatype of the column at the given position from its non-null raw values.
+// Integers are preferred over floats, floats over booleans, booleans over date-times
+// (in the given layout) and date-times over strings
+func inferCSVDatatype(rows [][]string, position int, nullTokens map[string]struct{}, layout string) Datatype {
+	candidates := []Datatype{IntType, FloatType, BooleanType, DateTimeType}
+	hasValues := false
+
+	for _, row := range rows {
+		raw := row[position]
+		if _, isNull := nullTokens[raw]; isNull {
+			continue
+		}
+
+		hasValues = true
+		remaining := candidates[:0]
+
+		for _, dtype := range candidates {
+			if _, err := parseCSVValue(raw, dtype, layout); err == nil {
+				remaining = append(remaining, dtype)
+			}
+		}
+
+		candidates = remaining
+		if len(candidates) == 0 {
+			return StringType
+		}
+	}
+
+	if !hasValues {
+		return StringType
+	}
+
+	return candidates[0]
+}
+
+// Parses the raw CSV value into a value of the given Datatype, date-times being in the given layout
+func parseCSVValue(raw string, dtype Datatype, layout string) (interface{}, error) {
+	switch dtype {
+	case IntType:
+		value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return int(value), nil
+	case FloatType:
+		return strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	case BooleanType:
+		return strconv.ParseBool(strings.TrimSpace(raw))
+	case DateTimeType:
+		return time.Parse(layout, strings.TrimSpace(raw))
+	default:
+		return raw, nil
+	}
+}
+
+// Converts a value to its CSV text form, date-times being in the given layout
+func formatCSVValue(value interface{}, layout string) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(layout)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// Checks whether the value is a number or a boolean
+func isNumericOrBool(value interface{}) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,208 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// FromCSV should create a dataframe from CSV data, inferring the types of the columns if required
+func TestFromCSV(t *testing.T)  {
+	data := "first name,last name,age,location\n" +
+		"John,Doe,30,Kampala\n" +
+		"Jane,Doe,50,Lusaka\n" +
+		"Paul,Doe,19,Kampala\n" +
+		"Richard,Roe,34,Nairobi\n" +
+		"Reyna,Roe,45,Nairobi\n" +
+		"Ruth,Roe,60,Kampala\n"
+
+	df, err := FromCSV(strings.NewReader(data), CSVOptions{InferTypes: true, PrimaryFields: primaryFields})
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	colNames := utils.SortStringSlice(df.ColumnNames(), utils.ASC)
+	if !utils.AreStringSliceEqual(colNames, expectedCols){
+		t.Fatalf("cols expected: %v, got: %v", expectedCols, colNames)
+	}
+
+	if !utils.AreStringSliceEqual(keys, df.Keys()) {
+		t.Fatalf("keys expected: %v, got: %v", keys, df.Keys())
+	}
+
+	for _, col := range df.cols {
+		expectedItems := utils.ExtractFieldFromMapList(dataArray, col.Name)
+		if !utils.AreSliceEqual(col.Items(), expectedItems){
+			t.Fatalf("col '%s' items expected: %v, got %v", col.Name, expectedItems, col.Items())
+		}
+	}
+
+	if df.Col("age").Dtype() != IntType {
+		t.Fatalf("dtype of 'age' expected: %v, got %v", IntType, df.Col("age").Dtype())
+	}
+
+	if df.Col("location").Dtype() != StringType {
+		t.Fatalf("dtype of 'location' expected: %v, got %v", StringType, df.Col("location").Dtype())
+	}
+}
+
+// FromCSV should respect the delimiter, the missing header row, null tokens and the given Dtypes
+func TestFromCSVWithOptions(t *testing.T)  {
+	data := "1;\"John\";2.5;true\n" +
+		"2;Jane;NA;false\n" +
+		"3;NA;7;NA\n"
+
+	opts := CSVOptions{
+		Delimiter: ';',
+		NoHeader: true,
+		Columns: []string{"id", "name", "score", "active"},
+		NullTokens: []string{"NA"},
+		InferTypes: true,
+		Dtypes: map[string]Datatype{"id": StringType},
+		PrimaryFields: []string{"id"},
+	}
+
+	df, err := FromCSV(strings.NewReader(data), opts)
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	expected := map[string][]interface{}{
+		"id": {"1", "2", "3"},
+		"name": {"John", "Jane", nil},
+		"score": {2.5, nil, 7.0},
+		"active": {true, false, nil},
+	}
+	expectedDtypes := map[string]Datatype{"id": StringType, "name": StringType, "score": FloatType, "active": BooleanType}
+
+	for field, expectedItems := range expected {
+		col := df.Col(field)
+		if !utils.AreSliceEqual(col.Items(), expectedItems){
+			t.Fatalf("col '%s' items expected: %v, got %v", field, expectedItems, col.Items())
+		}
+
+		if col.Dtype() != expectedDtypes[field] {
+			t.Fatalf("col '%s' dtype expected: %v, got %v", field, expectedDtypes[field], col.Dtype())
+		}
+	}
+}
+
+// ToCSV should write the records as CSV, with the primary fields first and the rest in ascending order by default
+func TestDataframe_ToCSV(t *testing.T)  {
+	df, err := FromArray([]map[string]interface{}{
+		{"id": 1, "name": "John, Jr", "score": 2.5, "active": true},
+		{"id": 2, "name": "Jane \"J\"", "score": nil, "active": false},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	type testRecord struct {
+		opts CSVOptions;
+		expected string;
+	}
+
+	testData := []testRecord{
+		{
+			opts: CSVOptions{},
+			expected: "id,active,name,score\n" +
+				"1,true,\"John, Jr\",2.5\n" +
+				"2,false,\"Jane \"\"J\"\"\",\n",
+		},
+		{
+			opts: CSVOptions{Delimiter: '\t', NoHeader: true, Columns: []string{"name", "score"}, NullTokens: []string{"NULL"}},
+			expected: "John, Jr\t2.5\n" +
+				"\"Jane \"\"J\"\"\"\tNULL\n",
+		},
+		{
+			opts: CSVOptions{Quoting: QUOTE_ALL, Columns: []string{"id", "name"}},
+			expected: "\"id\",\"name\"\n" +
+				"\"1\",\"John, Jr\"\n" +
+				"\"2\",\"Jane \"\"J\"\"\"\n",
+		},
+		{
+			opts: CSVOptions{Quoting: QUOTE_NONNUMERIC, Columns: []string{"id", "active", "score"}},
+			expected: "\"id\",\"active\",\"score\"\n" +
+				"1,true,2.5\n" +
+				"2,false,\"\"\n",
+		},
+	}
+
+	for _, tr := range testData {
+		var output strings.Builder
+		err = df.ToCSV(&output, tr.opts)
+		if err != nil {
+			t.Fatalf("ToCSV error is: %s", err)
+		}
+
+		if output.String() != tr.expected {
+			t.Fatalf("expected %q; got %q", tr.expected, output.String())
+		}
+	}
+}
+
+// Data written by ToCSV should be read back by FromCSV as it was
+func TestDataframe_ToCSVRoundTrip(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	var output strings.Builder
+	err = df.ToCSV(&output, CSVOptions{})
+	if err != nil {
+		t.Fatalf("ToCSV error is: %s", err)
+	}
+
+	newDf, err := FromCSV(strings.NewReader(output.String()), CSVOptions{InferTypes: true, PrimaryFields: primaryFields})
+	if err != nil {
+		t.Fatalf("FromCSV error is: %s", err)
+	}
+
+	records, err := newDf.ToArray()
+	if err != nil {
+		t.Fatalf("error on ToArray is: %s", err)
+	}
+
+	for i, record := range dataArray {
+		for field, expected := range record {
+			if expected != records[i][field] {
+				t.Fatalf("the record %d expected %v, got %v", i, expected, records[i][field])
+			}
+		}
+	}
+}
+
+// FromCSV and ToCSV should parse and format date-times in the given layout, inferring DateTimeType columns
+func TestFromCSVWithDateTimes(t *testing.T)  {
+	data := "id,date,note\n1,2021-03-04,2021-03-04\n2,,31/12/2020\n3,2020-12-31,\n"
+	opts := CSVOptions{InferTypes: true, DateTimeLayout: "2006-01-02", PrimaryFields: []string{"id"}}
+
+	df, err := FromCSV(strings.NewReader(data), opts)
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	if df.Col("date").Dtype() != DateTimeType || df.Col("note").Dtype() != StringType {
+		t.Fatalf("dtypes expected: date %v, note %v; got date %v, note %v",
+			DateTimeType, StringType, df.Col("date").Dtype(), df.Col("note").Dtype())
+	}
+
+	expected := []interface{}{time.Date(2021, time.March, 4, 0, 0, 0, 0, time.UTC), nil, time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC)}
+	if !utils.AreSliceEqual(df.Col("date").Items(), expected) {
+		t.Fatalf("date items expected: %v, got %v", expected, df.Col("date").Items())
+	}
+
+	var output strings.Builder
+	err = df.ToCSV(&output, CSVOptions{Columns: []string{"id", "date"}, DateTimeLayout: "02/01/2006"})
+	if err != nil {
+		t.Fatalf("ToCSV error is: %s", err)
+	}
+
+	expectedCSV := "id,date\n1,04/03/2021\n2,\n3,31/12/2020\n"
+	if output.String() != expectedCSV {
+		t.Fatalf("expected %q; got %q", expectedCSV, output.String())
+	}
+}
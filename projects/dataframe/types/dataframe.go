@@ -1,18 +1,45 @@
 package types
 
 import (
-	"encoding/json"
+	"container/heap"
 	"fmt"
+	"math"
+	"math/rand"
+	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/learn-along/learn-go/projects/dataframe/utils"
 )
 
+const (
+	// values that do not match the Dtype of their column change the column's Dtype
+	LENIENT strictnessMode = iota
+	// values that do not match the Dtype of their column are converted to it, or rejected if that is not possible
+	COERCE
+	// values that do not match the Dtype of their column are rejected
+	STRICT
+)
+
+type strictnessMode int
+
 type Dataframe struct {
 	cols map[string]*Column;
 	pkFields []string;
 	index map[interface{}]int;
+	strictness strictnessMode;
+	parallelism int;
+	// the number of versions whose columns are kept, 0 if versioning is off
+	versioning int;
+	// every version from version 0 to the latest, empty until the first mutation
+	history []Version;
+	// the versions whose columns are kept, from the oldest to the latest
+	versions []*snapshot;
+	// whether the dataframe is a view of a version, that cannot be mutated
+	isReadOnly bool;
+	// the subscribers to the changes made by the mutations
+	observers *observers;
 }
 
 // Constructs a Dataframe from an array of maps and returns a pointer to it
@@ -61,120 +88,159 @@ func FromMap(records map[interface{}]map[string]interface{}, primaryFields []str
 // Inserts items passed as a list of maps into the Dataframe,
 // It will overwrite any record whose primary field values match with the new records
 func (d *Dataframe) Insert(records []map[string]interface{}) error {
-	d.defragmentize()
+	// the records are inserted in a transaction so that a failed insert leaves the dataframe unchanged
+	tx := d.Begin()
 
 	// FIXME:
 	// To quicken this even further, we could transpose the matrix at this point 
 	// and have slices corresponding to each column. These can then be bulk inserted into the columns.
-	for _, record := range records {
-		err := d.insertRecord(record)
-		if err != nil {
-			// FIXME: This should probably rollback; might need to make snapshots
-			return err
-		}
-	}	
+	err := tx.Insert(records)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 
-	d.normalizeCols(nil)
-	return nil
+	return tx.Commit()
 }
 
 // Deletes the items that fulfill the filters
 func (d *Dataframe) Delete(filter filterType) error {
-	count := d.Count()
-	indicesToDelete := make([]int, count)
-	pkIndices := d.getIndicesInOrder()
-	keys := d.Keys()
-
-	counter := 0
-	for i, shouldDelete := range filter {
-		// FIXME:
-		// aside from the mutation delete(d.index,..) which might have race conditions,
-		// these others could be done concurrently
-		// as they don't affect themselves.
-		if shouldDelete && i < count {
-			indicesToDelete[counter] = pkIndices[i]
-			counter++
-
-			// FIXME:
-			// remove this from here. Look for a bulk way of removing keys from a map quickly
-			delete(d.index, keys[i])
-		}		
+	err := d.checkIsWritable()
+	if err != nil {
+		return err
 	}
 
-	// delete the items in each col 
-	for _, col := range d.cols {
-		// FIXME:
-		// These again can be done concurrently since the data is saved in separate columns.
-		col.deleteMany(indicesToDelete[:counter])
+	d.initHistory()
+
+	var changes []RecordChange
+	if d.hasSubscribers() {
+		changes = d.getChanges(DELETED, filter)
 	}
 
-	// defragmentize the pks, index and cols 
-	d.defragmentize()
+	d.deleteRows(filter)
+	d.commitVersion("delete", changes)
 
 	return nil
 }
 
 // Updates the items that fulfill the given filters with the new value
 func (d *Dataframe) Update(filter []bool, value map[string]interface{}) error  {
-	count := d.Count()
-	sizeOfValue := len(value)
-	indicesToUpdate := make([]int, count)
-	pkIndices := d.getIndicesInOrder()
-	valueCopy := make(map[string]interface{}, sizeOfValue)
-	pkFieldMap := d.getPkFieldMap()
+	err := d.checkIsWritable()
+	if err != nil {
+		return err
+	}
 
-	counter := 0
-	for i, shouldUpdate := range filter {
-		// FIXME: Concurrency should be possible here, possibly by ranging over 0 to len(filter)
-		// The pkIndex could be pushed to a channel and another goroutine just updates that index
-		if shouldUpdate && i < count {
-			indicesToUpdate[counter] = pkIndices[i]		
-			counter++	
-		}		
+	d.initHistory()
+
+	var changes []RecordChange
+	isTracked := d.hasSubscribers()
+	if isTracked {
+		changes = d.getChanges(UPDATED, filter)
 	}
 
-	for k, v := range value {
-		// FIXME: Concurrency possible
-		if _, ok := pkFieldMap[k]; !ok {
-			valueCopy[k] = v
-		}
+	err = d.updateRows(filter, value)
+	if err != nil {
+		return err
 	}
 
-	// update only upto counter
-	// This could a range over a channel instead...see FIXME at "for i, shouldUpdate := range filter"
-	for _, pkIndex := range indicesToUpdate[:counter] {
-		// FIXME: concurrenyc is possible for this inner loop
-		for colName, v := range valueCopy {		
-			d.Col(colName).insert(pkIndex, v)			
-		}		
+	if isTracked {
+		d.setValuesAfter(changes)
 	}
 
+	d.commitVersion("update", changes)
 	return nil
 }
 
+// Sets how values that do not match the Dtypes of their columns are handled on Insert and Update.
+// It returns the same dataframe to allow chaining
+func (d *Dataframe) WithStrictness(mode strictnessMode) *Dataframe {
+	d.strictness = mode
+	return d
+}
+
+// Sets the number of goroutines that share the work of operations that can be split across columns
+// or across chunks of rows, like filters, Apply, Insert, Update, Delete and ToArray.
+// A value of 1 or less runs them serially, which is the default. The results are the same whatever the value,
+// as long as the functions passed to Apply are safe to call concurrently.
+// It returns the same dataframe to allow chaining
+func (d *Dataframe) WithParallelism(n int) *Dataframe {
+	d.parallelism = n
+	for _, col := range d.cols {
+		col.parallelism = n
+	}
+
+	return d
+}
+
 // Selects a given number of fields, and returns a query instance of the same
 func (d *Dataframe) Select(fields ...string) *query {
 	// Creates a new query with this df and one SELECT action in the ops list
 	return &query{df: d, ops: []action{{_type: SELECT_ACTION, payload: fields}}}
 }
 
+// Returns a copy of the dataframe with only its first n rows
+func (d *Dataframe) Head(n int) (*Dataframe, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("head error: the number of rows %d is negative", n)
+	}
+
+	return d.Select().Limit(n).Collect()
+}
+
+// Returns a copy of the dataframe with only its last n rows
+func (d *Dataframe) Tail(n int) (*Dataframe, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("tail error: the number of rows %d is negative", n)
+	}
+
+	offset := d.Count() - n
+	if offset < 0 {
+		offset = 0
+	}
+
+	return d.Select().Offset(offset).Collect()
+}
+
+// Returns a copy of the dataframe with a random sample of its rows, in their original order.
+// A size of 1 or more is the number of rows, and a size below 1 is the fraction of the rows e.g. 0.1 for 10%.
+// The same seed always gives the same sample of the same dataframe
+func (d *Dataframe) Sample(size float64, seed int64) (*Dataframe, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("sample error: the size %v is negative", size)
+	}
+
+	count := d.Count()
+	n := int(size)
+	if size < 1 {
+		n = int(math.Round(size * float64(count)))
+	}
+
+	if n > count {
+		n = count
+	}
+
+	filter := make(filterType, count)
+	for _, position := range rand.New(rand.NewSource(seed)).Perm(count)[:n] {
+		filter[position] = true
+	}
+
+	return d.scan(d.ColumnNames(), filter, 0, -1), nil
+}
+
 // Merges the dataframes dfs to d
 func (d *Dataframe) Merge(dfs ...*Dataframe) error {
-	for _, df := range dfs {
-		// FIXME: Is it possible to merge without having to change to row-wise structure first.
-		// that is basing on the assumption that columnar is more efficient as we claimed
-		records, err := df.ToArray()
-		if err != nil {
-			return err
-		}
+	// the dataframes are merged in a transaction so that a failed merge leaves the dataframe unchanged
+	tx := d.Begin()
 
-		err = d.Insert(records)
-		if err != nil {
-			return err
-		}
+	// FIXME: Is it possible to merge without having to change to row-wise structure first.
+	// that is basing on the assumption that columnar is more efficient as we claimed
+	err := tx.Merge(dfs...)
+	if err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // Returns the number of actual active items
@@ -184,14 +250,34 @@ func (d *Dataframe) Count() int {
 
 // Copies the dataframe and returns the new copy
 func (d *Dataframe) Copy() (*Dataframe, error) {
-	// FIXME: Why not just copy the columns, the index, and the pkFields
-	// Having to call ToArray is very inefficient 
-	records, err := d.ToArray()
-	if err != nil {
-		return nil, err
+	newDf := Dataframe{
+		pkFields: d.pkFields,
+		cols: make(map[string]*Column, len(d.cols)),
+		index: make(map[interface{}]int, len(d.index)),
+		strictness: d.strictness,
+		parallelism: d.parallelism,
+		versioning: d.versioning,
+		// the history and versions never change, so the copy can share them, but not append to the same arrays
+		history: d.history[:len(d.history):len(d.history)],
+		versions: d.versions[:len(d.versions):len(d.versions)],
 	}
 
-	return FromArray(records, d.pkFields)
+	for key, row := range d.index {
+		newDf.index[key] = row
+	}
+
+	names, cols := d.getColList()
+	copies := make([]*Column, len(cols))
+	runTasks(d.parallelism, len(cols), func(i int) error {
+		copies[i] = cols[i].copy()
+		return nil
+	})
+
+	for i, name := range names {
+		newDf.cols[name] = copies[i]
+	}
+
+	return &newDf, nil
 }
 
 // Converts that dataframe into a slice of records (maps). If selectedFields is a non-empty slice 
@@ -213,26 +299,42 @@ func (d *Dataframe) ToArray(selectedFields ...string) ([]map[string]interface{},
 		cols = d.cols
 	}
 
-	for i, pkIndex := range pkIndices {
-		record := map[string]interface{}{}
+	// the records are independent so chunks of them are built concurrently
+	runInChunks(d.parallelism, count, func(start int, end int) error {
+		for i := start; i < end; i++ {
+			record := make(map[string]interface{}, len(cols))
+			for _, col := range cols {
+				record[col.Name] = col.get(pkIndices[i])
+			}
 
-		// FIXME: The column names are unique, the columns are independent, concurrency is thus possible
-		for _, col := range cols {
-			if i < len(col.items) {
-				record[col.Name] = col.items[pkIndex]
-			} else {
-				record[col.Name] = nil
-			}			
+			data[i] = record
 		}
-		
-		data[i] = record
-	}
+
+		return nil
+	})
 
 	return data, nil
 }
 
 // Clears all the data held by the dataframe except the primary key fields
-func (d *Dataframe) Clear() {
+func (d *Dataframe) Clear() error {
+	err := d.checkIsWritable()
+	if err != nil {
+		return err
+	}
+
+	d.initHistory()
+
+	var changes []RecordChange
+	if d.hasSubscribers() {
+		all := make(filterType, d.Count())
+		for i := range all {
+			all[i] = true
+		}
+
+		changes = d.getChanges(DELETED, all)
+	}
+
 	// clear the cols
 	for k := range d.cols {
 		// FIXME: can be done concurrently
@@ -244,16 +346,18 @@ func (d *Dataframe) Clear() {
 		// FIXME: Can be done concurrently
 		delete(d.index, k)
 	}	
+
+	d.commitVersion("clear", changes)
+	return nil
 }
 
-// Gets the pointer to a given column, or creates it if it does not exist
+// Gets the pointer to a given column. If it does not exist, an empty column that is not part of the dataframe
+// is returned, so that reading a missing column does not change the dataframe
 func (d *Dataframe) Col(name string) *Column {
 	col := d.cols[name]
 
 	if col == nil {
-		newCol := Column{Name: name, items: map[int]interface{}{}, Dtype: ObjectType}
-		d.cols[name] = &newCol 
-		return &newCol
+		return newUntypedColumn(name)
 	}
 
 	return col
@@ -262,7 +366,8 @@ func (d *Dataframe) Col(name string) *Column {
 // Access method to return the keys in order
 func (d *Dataframe) Keys() []string {
 	count := len(d.index)
-	orderedKeyMap := make(orderedMapType, count)
+	keys := make([]string, count)
+	isContiguous := true
 
 	// FIXME:
 	// Could we cache this result and save it on the dataframe itself,
@@ -271,10 +376,29 @@ func (d *Dataframe) Keys() []string {
 	// but then again, since it is a method, we could leave it to the user to cache
 	// that result themselves
 	for key, i := range d.index {
-		orderedKeyMap[i] = key
+		if i >= count {
+			isContiguous = false
+			break
+		}
+
+		keys[i] = key.(string)
+	}
+
+	if isContiguous {
+		return keys
+	}
+
+	// there are gaps in the rows, e.g. in the middle of a delete, so map the rows to their positions first
+	positions := make(map[int]int, count)
+	for position, row := range d.getIndicesInOrder() {
+		positions[row] = position
+	}
+
+	for key, row := range d.index {
+		keys[positions[row]] = key.(string)
 	}
 
-	return utils.ConvertToStringSlice(orderedKeyMap.ToSlice(), true)
+	return keys
 }
 
 // access method to return all column names
@@ -303,17 +427,7 @@ func (d *Dataframe) PrettyPrintRecords() error {
 	// | Col 1   | Col 2   | Col 3 | Col 4    |
 	// ----------------------------------------
 	// | foo     | 45      | 90    | hyu      |
-	data, err := d.ToArray()
-	if err != nil {
-		return err
-	}
-
-	dataJSON, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	return utils.PrettyPrintJSON(dataJSON)
+	return d.WriteJSON(os.Stdout, JSONOptions{Indent: "\t"})
 }
 
 // Returns the indices of the pks that have not been deleted, i.e. that have no nil
@@ -333,6 +447,148 @@ func (d *Dataframe) getIndicesInOrder() []int {
 	return indices
 }
 
+// Calls fn on every record in order, passing the values of the given fields in the same order as the fields.
+// Fields that do not exist in the dataframe get nil values. The row slice is reused between calls
+func (d *Dataframe) iterRows(fields []string, fn func(row []interface{}) error) error {
+	pkIndices := d.getIndicesInOrder()
+	cols := make([]*Column, len(fields))
+	row := make([]interface{}, len(fields))
+
+	for i, field := range fields {
+		cols[i] = d.cols[field]
+	}
+
+	for _, pkIndex := range pkIndices {
+		for i, col := range cols {
+			if col == nil {
+				row[i] = nil
+				continue
+			}
+
+			row[i] = col.get(pkIndex)
+		}
+
+		err := fn(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the column names with the primary key fields first, followed by the rest in ascending order
+func (d *Dataframe) orderedColumnNames() []string {
+	names := make([]string, 0, len(d.cols))
+	pkFieldMap := d.getPkFieldMap()
+
+	for _, field := range d.pkFields {
+		if _, ok := d.cols[field]; ok {
+			names = append(names, field)
+		}
+	}
+
+	others := []string{}
+	for name := range d.cols {
+		if _, ok := pkFieldMap[name]; !ok {
+			others = append(others, name)
+		}
+	}
+
+	return append(names, utils.SortStringSlice(others, utils.ASC)...)
+}
+
+// Deletes the rows that fulfill the filters, without making a version
+func (d *Dataframe) deleteRows(filter filterType) {
+	count := d.Count()
+	keys := d.Keys()
+
+	for i, shouldDelete := range filter {
+		if shouldDelete && i < count {
+			// FIXME:
+			// remove this from here. Look for a bulk way of removing keys from a map quickly
+			delete(d.index, keys[i])
+		}		
+	}
+
+	// drop the deleted rows from the cols, and reorder the index
+	d.defragmentize()
+}
+
+// Updates the rows that fulfill the filters with the new value, without making a version
+func (d *Dataframe) updateRows(filter []bool, value map[string]interface{}) error  {
+	count := d.Count()
+	sizeOfValue := len(value)
+	indicesToUpdate := make([]int, count)
+	pkIndices := d.getIndicesInOrder()
+	valueCopy := make(map[string]interface{}, sizeOfValue)
+	pkFieldMap := d.getPkFieldMap()
+
+	counter := 0
+	for i, shouldUpdate := range filter {
+		// FIXME: Concurrency should be possible here, possibly by ranging over 0 to len(filter)
+		// The pkIndex could be pushed to a channel and another goroutine just updates that index
+		if shouldUpdate && i < count {
+			indicesToUpdate[counter] = pkIndices[i]		
+			counter++	
+		}		
+	}
+
+	for k, v := range value {
+		// FIXME: Concurrency possible
+		if _, ok := pkFieldMap[k]; !ok {
+			preparedValue, err := d.prepareValue(k, v)
+			if err != nil {
+				return err
+			}
+
+			valueCopy[k] = preparedValue
+		}
+	}
+
+	colNames := make([]string, 0, len(valueCopy))
+	cols := make([]*Column, 0, len(valueCopy))
+	for colName := range valueCopy {
+		colNames = append(colNames, colName)
+		cols = append(cols, d.getOrAddCol(colName))
+	}
+
+	// the columns are independent so they are updated concurrently, each upto counter
+	return runTasks(d.parallelism, len(cols), func(i int) error {
+		for _, pkIndex := range indicesToUpdate[:counter] {
+			cols[i].insert(pkIndex, valueCopy[colNames[i]])
+		}
+
+		return nil
+	})
+}
+
+// Gets the pointer to a given column, or adds it to the dataframe if it does not exist
+func (d *Dataframe) getOrAddCol(name string) *Column {
+	col := d.cols[name]
+
+	if col == nil {
+		col = newUntypedColumn(name)
+		col.parallelism = d.parallelism
+		d.cols[name] = col
+	}
+
+	return col
+}
+
+// Returns the names of the columns and the columns in the same order, so that they can be shared among goroutines
+func (d *Dataframe) getColList() ([]string, []*Column) {
+	names := make([]string, 0, len(d.cols))
+	cols := make([]*Column, 0, len(d.cols))
+
+	for name, col := range d.cols {
+		names = append(names, name)
+		cols = append(cols, col)
+	}
+
+	return names, cols
+}
+
 // Inserts a single record
 func (d *Dataframe) insertRecord(record map[string]interface{}) error {
 	key, err := createKey(record, d.pkFields)
@@ -340,6 +596,17 @@ func (d *Dataframe) insertRecord(record map[string]interface{}) error {
 		return fmt.Errorf("failed to create key for %v using field %v", record, d.pkFields)
 	}
 
+	record, err = d.prepareRecord(record)
+	if err != nil {
+		return err
+	}
+
+	d.setRecord(key, record)
+	return nil
+}
+
+// Sets the prepared record at the row of the given key, adding a row for the key if it has none
+func (d *Dataframe) setRecord(key string, record map[string]interface{}) {
 	row, ok := d.index[key]
 	if !ok {
 		row = len(d.index)
@@ -350,27 +617,126 @@ func (d *Dataframe) insertRecord(record map[string]interface{}) error {
 		// FIXME:
 		// to take advantage of having values in separate columns,
 		// these values can be saved concurrently
-		col := d.Col(fieldName)			
+		col := d.getOrAddCol(fieldName)			
 		col.insert(row, value)
 	}
+}
+
+// Builds the index of a dataframe whose columns are filled, using the values of its primary fields
+func (d *Dataframe) buildIndex() error {
+	// columns added by an update may not reach the last rows
+	count := 0
+	for _, col := range d.cols {
+		if col.Len() > count {
+			count = col.Len()
+		}
+	}
+
+	pkCols := make([]*Column, len(d.pkFields))
+	for i, field := range d.pkFields {
+		pkCols[i] = d.cols[field]
+	}
+
+	record := make(map[string]interface{}, len(d.pkFields))
+
+	for row := 0; row < count; row++ {
+		for i, field := range d.pkFields {
+			if pkCols[i] != nil {
+				record[field] = pkCols[i].get(row)
+			}
+		}
+
+		key, err := createKey(record, d.pkFields)
+		if err != nil {
+			return err
+		}
+
+		if _, exists := d.index[key]; exists {
+			return fmt.Errorf("duplicate primary key %v for fields %v", key, d.pkFields)
+		}
+
+		d.index[key] = row
+	}
 
 	return nil
 }
 
+// Drops all the columns except the given fields. Fields that do not exist are ignored.
+// If none of the fields exist, all columns are kept, the same way ToArray does
+func (d *Dataframe) keepCols(fields ...string) {
+	kept := make(map[string]*Column, len(fields))
+
+	for _, field := range fields {
+		if col, ok := d.cols[field]; ok {
+			kept[field] = col
+		}
+	}
+
+	if len(kept) > 0 {
+		d.cols = kept
+	}
+}
+
+// Checks the values of the record against the Dtypes of their columns basing on the strictness of the dataframe,
+// returning the record with any coerced values. The record passed is not mutated
+func (d *Dataframe) prepareRecord(record map[string]interface{}) (map[string]interface{}, error) {
+	if d.strictness == LENIENT {
+		return record, nil
+	}
+
+	prepared := make(map[string]interface{}, len(record))
+	for field, value := range record {
+		preparedValue, err := d.prepareValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+
+		prepared[field] = preparedValue
+	}
+
+	return prepared, nil
+}
+
+// Checks the value against the Dtype of the column of the given field basing on the strictness of the dataframe,
+// returning the value, coerced if need be
+func (d *Dataframe) prepareValue(field string, value interface{}) (interface{}, error) {
+	col, ok := d.cols[field]
+	if !ok || col.fits(value) {
+		return value, nil
+	}
+
+	switch d.strictness {
+	case COERCE:
+		coerced, err := coerceValue(value, col.Dtype())
+		if err != nil {
+			return nil, fmt.Errorf("type error: cannot coerce %v (%T) to the %v of column '%s': %s", value, value, col.Dtype(), field, err)
+		}
+		return coerced, nil
+	case STRICT:
+		return nil, fmt.Errorf("type error: %v (%T) does not match the %v of column '%s'", value, value, col.Dtype(), field)
+	}
+
+	return value, nil
+}
+
 // Fills up the columns with the given value to reach a given length for all columns
 func (d *Dataframe) normalizeCols(defaultValue interface{})  {
 	pkIndices := d.getIndicesInOrder()
 	finalLength := len(pkIndices)
+	_, cols := d.getColList()
 
-	for _, col := range d.cols {
-		// FIXME: This could be done concurrently as the cols are independent of each other
-		colLength := len(col.items)
-		
-		for i := colLength; i < finalLength; i++ {
-			pkIndex := pkIndices[i]
+	// the cols are independent of each other so they are normalized concurrently
+	runTasks(d.parallelism, len(cols), func(i int) error {
+		col := cols[i]
+		colLength := col.Len()
+
+		for j := colLength; j < finalLength; j++ {
+			pkIndex := pkIndices[j]
 			col.insert(pkIndex, defaultValue)
 		}
-	}
+
+		return nil
+	})
 }
 
 // Converts the primary key field list to a map for easy checking against, to see if field is pkField or not
@@ -406,13 +772,28 @@ func createKey(record map[string]interface{}, primaryFields []string) (string, e
 func (d *Dataframe) defragmentize()  {
 	pkIndices := d.getIndicesInOrder()
 	keys := d.Keys()
-
-	for _, col := range d.cols {
-		// FIXME:
-		// These columns are independent. Their defragmentation can be done concurrently
-		col.items.Defragmentize(pkIndices)
+	count := len(pkIndices)
+	length := 0
+	if count > 0 {
+		length = pkIndices[count-1] + 1
 	}
 
+	// the columns are independent so they are defragmented concurrently
+	_, cols := d.getColList()
+	runTasks(d.parallelism, len(cols), func(i int) error {
+		col := cols[i]
+		if colLength := col.Len(); colLength < length {
+			col.grow(length - colLength)
+		}
+
+		// the sorted indices are unique, so if the last one is count - 1, there are no gaps
+		if length != count || col.Len() != count {
+			col.take(pkIndices)
+		}
+
+		return nil
+	})
+
 	for newRow, key := range keys {
 		// FIXME:
 		// This could be done concurrently
@@ -421,36 +802,83 @@ func (d *Dataframe) defragmentize()  {
 	}
 }
 
-// Filters this dataframe and returns the filtered **copy** of this dataframe
-func (d *Dataframe) getFilteredDf(filter filterType) (*Dataframe, error) {
-	newDf, err := d.Copy()
-	if err != nil {
-		return nil, err
+// Returns a copy of the dataframe with only the given columns, and only the rows that pass the filter.
+// Rows beyond the length of the filter are kept, and a nil filter keeps all rows.
+// Of the rows that pass, the first offset rows are skipped and at most limit rows are kept,
+// reading no more rows once the limit is reached. A negative limit keeps all the rows
+func (d *Dataframe) scan(columns []string, filter filterType, offset int, limit int) *Dataframe {
+	newDf := Dataframe{
+		pkFields: d.pkFields,
+		cols: make(map[string]*Column, len(columns)),
+		index: make(map[interface{}]int, len(d.index)),
+		strictness: d.strictness,
+		parallelism: d.parallelism,
 	}
 
-	if filter == nil {
-		return newDf, nil
-	}
+	indices := d.getIndicesInOrder()
+	rows := make([]int, 0, len(indices))
 
-	// toggle the values in the filter, and delete the unwanted items
-	for i, v := range filter {
-		// FIXME:
-		// This can be done concurrently
-		filter[i] = !v
+	for position, key := range d.Keys() {
+		if limit >= 0 && len(rows) >= limit {
+			break
+		}
+
+		if filter == nil || position >= len(filter) || filter[position] {
+			if offset > 0 {
+				offset--
+				continue
+			}
+
+			newDf.index[key] = len(rows)
+			rows = append(rows, indices[position])
+		}
 	}
 
-	err = newDf.Delete(filter)
-	if err != nil {
-		return nil, err
+	// if all rows are kept and there are no gaps, the columns can be copied as they are
+	isCopy := len(rows) == len(indices) && (len(rows) == 0 || rows[len(rows)-1] == len(rows)-1)
+
+	scanned := make([]*Column, len(columns))
+	runTasks(d.parallelism, len(columns), func(i int) error {
+		if col, ok := d.cols[columns[i]]; ok {
+			if isCopy {
+				scanned[i] = col.copy()
+			} else {
+				scanned[i] = col.gather(columns[i], rows)
+			}
+		}
+
+		return nil
+	})
+
+	for i, name := range columns {
+		if scanned[i] != nil {
+			newDf.cols[name] = scanned[i]
+		}
 	}
 
-	return newDf, nil
+	return &newDf
+}
+
+// Keeps only the limit rows after the first offset rows, deleting the rest. A negative limit keeps all rows after them.
+// It is meant for the results of queries, so it makes no version and tells no subscribers
+func (d *Dataframe) keepRows(offset int, limit int) {
+	filter := make(filterType, d.Count())
+	for i := range filter {
+		filter[i] = i < offset || (limit >= 0 && i >= offset+limit)
+	}
 
+	d.deleteRows(filter)
 }
 
 // Groups this dataframe, basing on the groupbyOption passed, and returns a new grouped Dataframe copy
 func (d *Dataframe) getGroupedDf(gopt *groupByOption) (*Dataframe, error) {
 	aggs := mergeAggregations(gopt.aggs)
+	for name, agg := range aggs {
+		if name != agg.field && utils.ContainsString(gopt.fields, name) {
+			return nil, fmt.Errorf("groupby error: the aggregation name '%s' is a grouped field", name)
+		}
+	}
+
 	groupedData := map[string][]map[string]interface{}{}
 	mergedRecords := []map[string]interface{}{}
 	index := []string{}
@@ -493,8 +921,8 @@ func (d *Dataframe) getGroupedDf(gopt *groupByOption) (*Dataframe, error) {
 			return nil, err
 		}
 
-		for field, aggFunc := range aggs {
-			mergedRecord[field] = aggFunc(df.Col(field).Items())
+		for name, agg := range aggs {
+			mergedRecord[name] = agg.apply(df.Col(agg.field).Items())
 		}
 
 		mergedRecords[i] = mergedRecord
@@ -518,70 +946,199 @@ func (d *Dataframe) getSortedDf(options... sortOption) (*Dataframe, error) {
 	}
 		
 	sort.SliceStable(records, func(i, j int) bool {
-		prev := records[i]
-		next := records[j]
-
-		for _, opt := range options {
-			for field, order := range opt {	
-				nextValue := next[field]
-				prevValue := prev[field]
+		return isRecordBefore(records[i], records[j], options)
+	})
+	
 
-				if nextValue == prevValue {
-					continue
-				}
+	return FromArray(records, d.pkFields)
+}
 
-				if prevValue == nil {
-					// nils will be pushed up by default
-					return order == ASC
-				}
+// Orders the items in the columns of this dataframe basing on the sort options passed, like getSortedDf,
+// but keeps only the first k rows. It only ever holds k records in order, in a heap,
+// instead of sorting all of them
+func (d *Dataframe) getTopKDf(k int, options... sortOption) (*Dataframe, error) {
+	records, err := d.ToArray()
+	if err != nil {
+		return nil, err
+	}
 
-				if nextValue == nil {
-					// nils will be pushed up by default
-					return order == DESC
-				}
+	// the heap has the last of the top records at its root, so that it is the one replaced by a record before it.
+	// Records are in their original order if they are equal for the sort options, as with getSortedDf
+	top := recordHeap{options: options}
+	for position, record := range records {
+		if k <= 0 {
+			break
+		}
 
-				switch p := prevValue.(type) {
-				case string:
-					if order == ASC {
-						return p < nextValue.(string)
-					} else {
-						return p > nextValue.(string)
-					}
-				default:
-					prevAsFloat := convertToFloat64(prevValue)
-					nextAsFloat := convertToFloat64(nextValue)
-
-					if order == ASC {
-						return prevAsFloat < nextAsFloat
-					} else {
-						return prevAsFloat > nextAsFloat
-					}
-				}	
-				
-			}
+		item := positionedRecord{position: position, record: record}
+		if top.Len() < k {
+			heap.Push(&top, item)
+		} else if top.isBefore(item, top.items[0]) {
+			top.items[0] = item
+			heap.Fix(&top, 0)
 		}
+	}
 
-		return true
-	})
-	
+	sort.Slice(top.items, func(i, j int) bool { return top.isBefore(top.items[i], top.items[j]) })
 
-	return FromArray(records, d.pkFields)
+	topRecords := make([]map[string]interface{}, len(top.items))
+	for i, item := range top.items {
+		topRecords[i] = item.record
+	}
+
+	return FromArray(topRecords, d.pkFields)
 }
 
 // Applys the given rowWiseFunc functions on the dataframe
 func (d *Dataframe) apply(rowWiseFuncMap map[string][]rowWiseFunc) error {
-	for field, txs := range rowWiseFuncMap {
-		// FIXME: This second for loop works on each field/column at a time,
-		// and so this should be done concurrently
-		for _, tx := range txs {
-			if col, ok := d.cols[field]; ok {
-				// FIXME: This third for loop works on individual items,
-				// and so this too can be done concurrently
-				for i, v := range col.items {
-					col.items[i] = tx(v)
+	fields := make([]string, 0, len(rowWiseFuncMap))
+	for field := range rowWiseFuncMap {
+		if _, ok := d.cols[field]; ok {
+			fields = append(fields, field)
+		}
+	}
+
+	newCols := make([]*Column, len(fields))
+
+	// each field is transformed on its own, with chunks of its items transformed concurrently
+	runTasks(d.parallelism, len(fields), func(i int) error {
+		field := fields[i]
+		newCol := d.cols[field]
+
+		for _, tx := range rowWiseFuncMap[field] {
+			items := newCol.Items()
+			runInChunks(d.parallelism, len(items), func(start int, end int) error {
+				for j := start; j < end; j++ {
+					items[j] = tx(items[j])
 				}
-			}	
-		}			
+
+				return nil
+			})
+
+			// the transformed values may be of a different type, so the Dtype is inferred afresh
+			newCol = newUntypedColumn(field)
+			newCol.parallelism = d.parallelism
+			for j, v := range items {
+				newCol.insert(j, v)
+			}
+		}
+
+		newCols[i] = newCol
+		return nil
+	})
+
+	for i, field := range fields {
+		d.cols[field] = newCols[i]
 	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+// Checks whether the first record comes before the second basing on the sort options passed.
+// It is false if they are equal for all the options
+func isRecordBefore(prev map[string]interface{}, next map[string]interface{}, options []sortOption) bool {
+	for _, opt := range options {
+		for field, order := range opt {	
+			// nils will be pushed up by default
+			result := compareValues(prev[field], next[field])
+			if result == 0 {
+				continue
+			}
+
+			return (result < 0) == (order == ASC)
+		}
+	}
+
+	return false
+}
+
+// Compares two values for ordering, returning -1, 0 or 1. nil comes before any other value,
+// date-times are compared as times, strings as strings and other values as float64 values
+func compareValues(first interface{}, second interface{}) int {
+	if first == nil || second == nil {
+		switch {
+		case first != nil:
+			return 1
+		case second != nil:
+			return -1
+		default:
+			return 0
+		}
+	}
+
+	firstTime, isFirstTime := first.(time.Time)
+	secondTime, isSecondTime := second.(time.Time)
+
+	if isFirstTime && isSecondTime {
+		switch {
+		case firstTime.Before(secondTime):
+			return -1
+		case firstTime.After(secondTime):
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	firstStr, isFirstStr := first.(string)
+	secondStr, isSecondStr := second.(string)
+
+	if isFirstStr || isSecondStr {
+		if !isFirstStr {
+			firstStr = fmt.Sprintf("%v", first)
+		}
+
+		if !isSecondStr {
+			secondStr = fmt.Sprintf("%v", second)
+		}
+
+		return strings.Compare(firstStr, secondStr)
+	}
+
+	firstFloat := convertToFloat64(first)
+	secondFloat := convertToFloat64(second)
+
+	switch {
+	case firstFloat < secondFloat:
+		return -1
+	case firstFloat > secondFloat:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// A record and its position in the dataframe
+type positionedRecord struct {
+	position int
+	record map[string]interface{}
+}
+
+// Max-heap of records, by the sort options, the last record being at the root
+type recordHeap struct {
+	options []sortOption
+	items []positionedRecord
+}
+
+func (h recordHeap) Len() int { return len(h.items) }
+func (h recordHeap) Less(i, j int) bool { return h.isBefore(h.items[j], h.items[i]) }
+func (h recordHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *recordHeap) Push(x interface{}) { h.items = append(h.items, x.(positionedRecord)) }
+func (h *recordHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// Checks whether the first record comes before the second, using their positions if they are equal
+func (h recordHeap) isBefore(first positionedRecord, second positionedRecord) bool {
+	if isRecordBefore(first.record, second.record, h.options) {
+		return true
+	}
+
+	if isRecordBefore(second.record, first.record, h.options) {
+		return false
+	}
+
+	return first.position < second.position
+}
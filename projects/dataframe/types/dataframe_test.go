@@ -580,6 +580,20 @@ func TestDataframe_Select(t *testing.T)  {
 				{"last name": "Roe", "age": float64(139) / 3},
 			},
 		},
+		{
+			// a column can be aggregated a number of times, if the results are given different names.
+			// Selecting the column selects the names of its aggregations
+			q: df.Select("age", "last name").GroupBy("last name").Agg(
+				df.Col("age").Agg(MIN).As("min_age"),
+				df.Col("age").Agg(MAX).As("max_age"),
+				df.Col("age").Agg(COUNT),
+				df.Col("first name").Agg(MAX).As("last first name"),
+			),
+			expected: []map[string]interface{}{
+				{"last name": "Doe", "min_age": 19.0, "max_age": 50.0, "age": 3},
+				{"last name": "Roe", "min_age": 34.0, "max_age": 60.0, "age": 3},
+			},
+		},
 		{
 			// Passing no fields in Select returns all columns
 			q: df.Select().Where(
@@ -844,3 +858,185 @@ func ExampleDataframe_PrettyPrintRecords()  {
 	// 	}
 	// ]
 }
+
+// FromArray should infer the Dtypes of the columns from their values
+func TestFromArrayInfersDtypes(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	expected := map[string]Datatype{"first name": StringType, "last name": StringType, "age": IntType, "location": StringType}
+	for field, dtype := range expected {
+		if df.Col(field).Dtype() != dtype {
+			t.Fatalf("col '%s' dtype expected: %v, got %v", field, dtype, df.Col(field).Dtype())
+		}
+	}
+}
+
+// Insert and Update should handle values that do not match the Dtypes of their columns basing on the strictness mode
+func TestDataframe_WithStrictness(t *testing.T)  {
+	type testRecord struct {
+		mode strictnessMode;
+		record map[string]interface{};
+		update map[string]interface{};
+		expectError bool;
+		expectedAges []interface{};
+		expectedDtype Datatype;
+	}
+
+	testData := []testRecord{
+		{
+			mode: LENIENT,
+			record: map[string]interface{}{"first name": "Roy", "last name": "Roe", "age": "old"},
+			expectedAges: []interface{}{30, 50, 19, 34, 45, 60, "old"},
+			expectedDtype: ObjectType,
+		},
+		{
+			mode: COERCE,
+			record: map[string]interface{}{"first name": "Roy", "last name": "Roe", "age": "70"},
+			expectedAges: []interface{}{30, 50, 19, 34, 45, 60, 70},
+			expectedDtype: IntType,
+		},
+		{
+			mode: COERCE,
+			record: map[string]interface{}{"first name": "Roy", "last name": "Roe", "age": "old"},
+			expectError: true,
+		},
+		{
+			mode: STRICT,
+			record: map[string]interface{}{"first name": "Roy", "last name": "Roe", "age": "70"},
+			expectError: true,
+		},
+		{
+			mode: STRICT,
+			update: map[string]interface{}{"age": 70.5},
+			expectError: true,
+		},
+		{
+			mode: COERCE,
+			update: map[string]interface{}{"age": 70.0},
+			expectedAges: []interface{}{70, 70, 70, 70, 70, 70},
+			expectedDtype: IntType,
+		},
+	}
+
+	for i, tr := range testData {
+		df, err := FromArray(dataArray, primaryFields)
+		if err != nil {
+			t.Fatalf("df error is: %s", err)
+		}
+
+		df.WithStrictness(tr.mode)
+
+		if tr.record != nil {
+			err = df.Insert([]map[string]interface{}{tr.record})
+		} else {
+			err = df.Update(df.Col("age").GreaterThan(0), tr.update)
+		}
+
+		if tr.expectError {
+			if err == nil {
+				t.Fatalf("record %d: expected an error", i)
+			}
+
+			expectedAges := utils.ExtractFieldFromMapList(dataArray, "age")
+			if !utils.AreSliceEqual(df.Col("age").Items(), expectedAges) {
+				t.Fatalf("record %d: ages expected: %v, got %v", i, expectedAges, df.Col("age").Items())
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("record %d: error is: %s", i, err)
+		}
+
+		if !utils.AreSliceEqual(df.Col("age").Items(), tr.expectedAges) {
+			t.Fatalf("record %d: ages expected: %v, got %v", i, tr.expectedAges, df.Col("age").Items())
+		}
+
+		if df.Col("age").Dtype() != tr.expectedDtype {
+			t.Fatalf("record %d: dtype expected: %v, got %v", i, tr.expectedDtype, df.Col("age").Dtype())
+		}
+	}
+}
+
+// Head and Tail should return copies with the first and last rows, and Sample a reproducible random sample
+func TestDataframe_HeadTailSample(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	type testRecord struct {
+		getDf func() (*Dataframe, error);
+		expectedKeys []string;
+	}
+
+	testData := []testRecord{
+		{getDf: func() (*Dataframe, error) { return df.Head(2) }, expectedKeys: []string{"John_Doe", "Jane_Doe"}},
+		{getDf: func() (*Dataframe, error) { return df.Head(10) }, expectedKeys: keys},
+		{getDf: func() (*Dataframe, error) { return df.Tail(2) }, expectedKeys: []string{"Reyna_Roe", "Ruth_Roe"}},
+		{getDf: func() (*Dataframe, error) { return df.Tail(0) }, expectedKeys: []string{}},
+		{getDf: func() (*Dataframe, error) { return df.Sample(0, 1) }, expectedKeys: []string{}},
+		{getDf: func() (*Dataframe, error) { return df.Sample(6, 1) }, expectedKeys: keys},
+		{getDf: func() (*Dataframe, error) { return df.Sample(1.0, 1) }, expectedKeys: nil},
+	}
+
+	for loop, tr := range testData {
+		newDf, err := tr.getDf()
+		if err != nil {
+			t.Fatalf("loop %d, error is: %s", loop, err)
+		}
+
+		if tr.expectedKeys != nil && !utils.AreStringSliceEqual(newDf.Keys(), tr.expectedKeys) {
+			t.Fatalf("loop %d, keys expected: %v, got %v", loop, tr.expectedKeys, newDf.Keys())
+		}
+
+		if tr.expectedKeys == nil && newDf.Count() != 1 {
+			t.Fatalf("loop %d, expected 1 row, got %v", loop, newDf.Keys())
+		}
+
+		if len(newDf.ColumnNames()) != noOfExpectedCols && newDf.Count() > 0 {
+			t.Fatalf("loop %d, expected %d columns, got %v", loop, noOfExpectedCols, newDf.ColumnNames())
+		}
+	}
+
+	first, err := df.Sample(0.5, 42)
+	if err != nil {
+		t.Fatalf("sample error is: %s", err)
+	}
+
+	second, err := df.Sample(3, 42)
+	if err != nil {
+		t.Fatalf("sample error is: %s", err)
+	}
+
+	if first.Count() != 3 || !utils.AreStringSliceEqual(first.Keys(), second.Keys()) {
+		t.Fatalf("expected the same 3 rows for the same seed, got %v and %v", first.Keys(), second.Keys())
+	}
+
+	// the sampled rows stay in their original order
+	position := map[string]int{}
+	for i, key := range keys {
+		position[key] = i
+	}
+
+	sampledKeys := first.Keys()
+	for i := 1; i < len(sampledKeys); i++ {
+		if position[sampledKeys[i-1]] > position[sampledKeys[i]] {
+			t.Fatalf("expected the sampled rows in their original order, got %v", sampledKeys)
+		}
+	}
+
+	for _, err := range []error{
+		func() error { _, err := df.Head(-1); return err }(),
+		func() error { _, err := df.Tail(-1); return err }(),
+		func() error { _, err := df.Sample(-0.5, 1); return err }(),
+	} {
+		if err == nil {
+			t.Fatalf("expected an error for a negative size")
+		}
+	}
+}
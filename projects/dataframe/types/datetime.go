@@ -0,0 +1,188 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	SECONDLY timePeriod = iota
+	MINUTELY
+	HOURLY
+	DAILY
+	// weeks start on Monday
+	WEEKLY
+	MONTHLY
+	QUARTERLY
+	YEARLY
+)
+
+var (
+	// transformations extracting the components of date-times as ints. They return nil for values that are not date-times
+	YEAR rowWiseFunc = getYear
+	MONTH rowWiseFunc = getMonth
+	DAY rowWiseFunc = getDay
+	// 0 for Sunday up to 6 for Saturday
+	WEEKDAY rowWiseFunc = getWeekday
+	HOUR rowWiseFunc = getHour
+	MINUTE rowWiseFunc = getMinute
+	SECOND rowWiseFunc = getSecond
+	// TRUNCATE(period) is the transformation truncating date-times to the start of their period
+	TRUNCATE = getTruncate
+)
+
+// Calendar period that date-times are truncated to
+type timePeriod int
+
+// Returns the name of the timePeriod
+func (p timePeriod) String() string {
+	switch p {
+	case SECONDLY:
+		return "SECONDLY"
+	case MINUTELY:
+		return "MINUTELY"
+	case HOURLY:
+		return "HOURLY"
+	case DAILY:
+		return "DAILY"
+	case WEEKLY:
+		return "WEEKLY"
+	case MONTHLY:
+		return "MONTHLY"
+	case QUARTERLY:
+		return "QUARTERLY"
+	case YEARLY:
+		return "YEARLY"
+	default:
+		return fmt.Sprintf("timePeriod(%d)", int(p))
+	}
+}
+
+// Groups the rows into buckets of the given period basing on the DateTimeType column, the field.
+// Each bucket is held in a single row whose field is the start of the bucket, in ascending order.
+// It works like GroupBy on the truncated field, so the other columns are combined using Agg.
+// Buckets without rows are left out
+func (q *query) Resample(field string, period timePeriod) *groupByOption {
+	return &groupByOption{q: q, fields: []string{field}, aggs: []aggregation{}, resample: &period}
+}
+
+// Returns the year of the date-time
+func getYear(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.Year()
+	}
+
+	return nil
+}
+
+// Returns the month (1 to 12) of the date-time
+func getMonth(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return int(t.Month())
+	}
+
+	return nil
+}
+
+// Returns the day of the month of the date-time
+func getDay(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.Day()
+	}
+
+	return nil
+}
+
+// Returns the day of the week of the date-time, 0 being Sunday
+func getWeekday(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return int(t.Weekday())
+	}
+
+	return nil
+}
+
+// Returns the hour of the date-time
+func getHour(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.Hour()
+	}
+
+	return nil
+}
+
+// Returns the minute of the date-time
+func getMinute(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.Minute()
+	}
+
+	return nil
+}
+
+// Returns the second of the date-time
+func getSecond(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.Second()
+	}
+
+	return nil
+}
+
+// Returns the transformation truncating date-times to the start of the given period.
+// It returns nil for values that are not date-times
+func getTruncate(period timePeriod) rowWiseFunc {
+	return func(value interface{}) interface{} {
+		if t, ok := value.(time.Time); ok {
+			return truncateTime(t, period)
+		}
+
+		return nil
+	}
+}
+
+/*
+* Helpers
+*/
+
+// Truncates the date-time to the start of the given period, in its own location
+func truncateTime(t time.Time, period timePeriod) time.Time {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	location := t.Location()
+
+	switch period {
+	case SECONDLY:
+		return time.Date(year, month, day, hour, minute, second, 0, location)
+	case MINUTELY:
+		return time.Date(year, month, day, hour, minute, 0, 0, location)
+	case HOURLY:
+		return time.Date(year, month, day, hour, 0, 0, 0, location)
+	case WEEKLY:
+		// the days since Monday
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, location)
+	case MONTHLY:
+		return time.Date(year, month, 1, 0, 0, 0, 0, location)
+	case QUARTERLY:
+		return time.Date(year, (month-1)/3*3+1, 1, 0, 0, 0, 0, location)
+	case YEARLY:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, location)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, location)
+	}
+}
+
+// Truncates the date-times of the field to the start of their periods, so that the rows can be grouped by them
+func (d *Dataframe) resample(field string, period timePeriod) error {
+	col, ok := d.cols[field]
+	if !ok {
+		return fmt.Errorf("resample error: column '%s' not found", field)
+	}
+
+	if col.Dtype() != DateTimeType {
+		return fmt.Errorf("resample error: column '%s' is of %v, not DateTimeType", field, col.Dtype())
+	}
+
+	return d.apply(map[string][]rowWiseFunc{field: {getTruncate(period)}})
+}
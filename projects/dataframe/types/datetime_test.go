@@ -0,0 +1,179 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+// DateTimeType columns should store time.Time values, be inferred from them and be cast to and from strings
+func TestColumn_DateTime(t *testing.T)  {
+	first := time.Date(2021, time.March, 4, 10, 30, 0, 0, time.UTC)
+	second := time.Date(2020, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+	col := newUntypedColumn("ts")
+	col.insert(0, nil)
+	col.insert(1, first)
+	col.insert(2, second)
+
+	if col.Dtype() != DateTimeType {
+		t.Fatalf("dtype expected: %v, got %v", DateTimeType, col.Dtype())
+	}
+
+	if col.get(0) != nil || col.get(1) != first || col.get(2) != second {
+		t.Fatalf("items expected: [<nil> %v %v], got %v", first, second, col.Items())
+	}
+
+	err := col.Cast(StringType)
+	if err != nil {
+		t.Fatalf("cast error is: %s", err)
+	}
+
+	if col.get(1) != "2021-03-04T10:30:00Z" {
+		t.Fatalf("expected %q, got %v", "2021-03-04T10:30:00Z", col.get(1))
+	}
+
+	err = col.Cast(DateTimeType)
+	if err != nil {
+		t.Fatalf("cast error is: %s", err)
+	}
+
+	if col.get(1) != first || col.get(0) != nil {
+		t.Fatalf("items expected: [<nil> %v %v], got %v", first, second, col.Items())
+	}
+
+	dates := newColumn("date", StringType)
+	dates.insert(0, "2021-03-04")
+	dates.insert(1, "04/03/2021")
+
+	err = dates.ParseDateTime("2006-01-02")
+	if err == nil {
+		t.Fatalf("expected an error for a date in another layout")
+	}
+
+	if dates.Dtype() != StringType {
+		t.Fatalf("the column should not change on error, got %v", dates.Dtype())
+	}
+
+	dates.insert(1, "2020-12-31")
+	err = dates.ParseDateTime("2006-01-02")
+	if err != nil {
+		t.Fatalf("parse error is: %s", err)
+	}
+
+	if dates.get(0) != time.Date(2021, time.March, 4, 0, 0, 0, 0, time.UTC) {
+		t.Fatalf("expected 2021-03-04, got %v", dates.get(0))
+	}
+}
+
+// The component transformations should extract parts of date-times, and TRUNCATE should truncate them to their period
+func TestDateTimeTransformations(t *testing.T)  {
+	// a Thursday
+	value := time.Date(2021, time.August, 19, 14, 35, 42, 500, time.UTC)
+
+	type testRecord struct {
+		tx rowWiseFunc;
+		expected interface{};
+	}
+
+	testData := []testRecord{
+		{tx: YEAR, expected: 2021},
+		{tx: MONTH, expected: 8},
+		{tx: DAY, expected: 19},
+		{tx: WEEKDAY, expected: 4},
+		{tx: HOUR, expected: 14},
+		{tx: MINUTE, expected: 35},
+		{tx: SECOND, expected: 42},
+		{tx: TRUNCATE(SECONDLY), expected: time.Date(2021, time.August, 19, 14, 35, 42, 0, time.UTC)},
+		{tx: TRUNCATE(MINUTELY), expected: time.Date(2021, time.August, 19, 14, 35, 0, 0, time.UTC)},
+		{tx: TRUNCATE(HOURLY), expected: time.Date(2021, time.August, 19, 14, 0, 0, 0, time.UTC)},
+		{tx: TRUNCATE(DAILY), expected: time.Date(2021, time.August, 19, 0, 0, 0, 0, time.UTC)},
+		{tx: TRUNCATE(WEEKLY), expected: time.Date(2021, time.August, 16, 0, 0, 0, 0, time.UTC)},
+		{tx: TRUNCATE(MONTHLY), expected: time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC)},
+		{tx: TRUNCATE(QUARTERLY), expected: time.Date(2021, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{tx: TRUNCATE(YEARLY), expected: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for i, tr := range testData {
+		got := tr.tx(value)
+		if got != tr.expected {
+			t.Fatalf("record %d: expected %v; got %v", i, tr.expected, got)
+		}
+
+		if tr.tx("2021-08-19") != nil {
+			t.Fatalf("record %d: expected nil for a value that is not a date-time", i)
+		}
+	}
+
+	// weeks start on Monday, even for Sundays
+	sunday := time.Date(2021, time.August, 22, 8, 0, 0, 0, time.UTC)
+	if got := TRUNCATE(WEEKLY)(sunday); got != time.Date(2021, time.August, 16, 0, 0, 0, 0, time.UTC) {
+		t.Fatalf("expected the Monday 2021-08-16, got %v", got)
+	}
+}
+
+// Resample should group the rows into time buckets in ascending order, and SortBy should order date-times by time
+func TestQuery_Resample(t *testing.T)  {
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2021, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	df, err := FromArray([]map[string]interface{}{
+		{"id": 1, "ts": day(time.March, 15), "at": day(time.March, 15), "sales": 10},
+		{"id": 2, "ts": day(time.January, 20), "at": day(time.January, 20), "sales": 5},
+		{"id": 3, "ts": day(time.March, 1), "at": day(time.March, 1), "sales": 20},
+		{"id": 4, "ts": day(time.January, 2), "at": day(time.January, 2), "sales": 7},
+		{"id": 5, "ts": day(time.May, 30), "at": day(time.May, 30), "sales": 1},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	records, err := df.Select("ts", "sales", "first", "last", "span").Resample("ts", MONTHLY).Agg(
+		df.Col("sales").Agg(SUM),
+		df.Col("sales").Agg(COUNT).As("count"),
+		df.Col("at").Agg(MIN).As("first"),
+		df.Col("at").Agg(MAX).As("last"),
+		df.Col("at").Agg(RANGE).As("span"),
+	).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	expected := []map[string]interface{}{
+		{"ts": time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), "sales": 12.0, "count": 2,
+			"first": day(time.January, 2), "last": day(time.January, 20), "span": 18 * 24 * time.Hour},
+		{"ts": time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC), "sales": 30.0, "count": 2,
+			"first": day(time.March, 1), "last": day(time.March, 15), "span": 14 * 24 * time.Hour},
+		{"ts": time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC), "sales": 1.0, "count": 1,
+			"first": day(time.May, 30), "last": day(time.May, 30), "span": time.Duration(0)},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %v", len(expected), records)
+	}
+
+	for i, record := range expected {
+		for field, expectedValue := range record {
+			if expectedValue != records[i][field] {
+				t.Fatalf("the record %d expected %v, got %v", i, record, records[i])
+			}
+		}
+	}
+
+	records, err = df.Select("id").SortBy(df.Col("ts").Order(DESC)).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	expectedIds := []interface{}{5, 1, 3, 2, 4}
+	for i, record := range records {
+		if record["id"] != expectedIds[i] {
+			t.Fatalf("the record %d expected id %v, got %v", i, expectedIds[i], records)
+		}
+	}
+
+	_, err = df.Select().Resample("sales", DAILY).Agg(df.Col("id").Agg(COUNT)).Execute()
+	if err == nil {
+		t.Fatalf("expected an error for resampling a column that is not a DateTimeType")
+	}
+}
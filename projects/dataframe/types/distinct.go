@@ -0,0 +1,124 @@
+package types
+
+import "fmt"
+
+const (
+	// keep the first of the duplicate rows
+	KEEP_FIRST keepMode = iota
+	// keep the last of the duplicate rows
+	KEEP_LAST
+	// keep none of the duplicate rows
+	KEEP_NONE
+)
+
+// Which of the rows with the same values to keep when dropping duplicates
+type keepMode int
+
+// Keeps only the first row for each distinct set of values of the fields, in the order of the result.
+// If no fields are given, the selected fields are used, or all fields if none are selected.
+// It is applied on the values after Apply, before Offset and Limit
+func (q *query) Distinct(fields ...string) *query {
+	q.ops = append(q.ops, action{_type: DISTINCT_ACTION, payload: fields})
+	return q
+}
+
+// Returns an array of booleans corresponding in position to each row,
+// true if the row has the same values for the subset of fields as an earlier row, or else false.
+// If no fields are given, all fields are compared. Fields that do not exist are nil for all rows
+func (d *Dataframe) Duplicated(subset ...string) filterType {
+	return d.getDuplicates(subset, KEEP_FIRST)
+}
+
+// Deletes the rows that have the same values for the subset of fields as another row,
+// keeping the first or the last of them, or none of them. If subset is empty, all fields are compared
+func (d *Dataframe) DropDuplicates(subset []string, keep keepMode) error {
+	err := d.checkDuplicatesOptions(subset, keep)
+	if err != nil {
+		return err
+	}
+
+	return d.Delete(d.getDuplicates(subset, keep))
+}
+
+/*
+* Helpers
+*/
+
+// Drops the duplicate rows as DropDuplicates does, but without making a version or telling the subscribers.
+// It is meant for the results of queries
+func (d *Dataframe) dropDuplicates(subset []string, keep keepMode) error {
+	err := d.checkDuplicatesOptions(subset, keep)
+	if err != nil {
+		return err
+	}
+
+	d.deleteRows(d.getDuplicates(subset, keep))
+	return nil
+}
+
+// Returns an error if any of the subset's columns does not exist or the keep mode is unknown
+func (d *Dataframe) checkDuplicatesOptions(subset []string, keep keepMode) error {
+	for _, field := range subset {
+		if _, ok := d.cols[field]; !ok {
+			return fmt.Errorf("duplicates error: column '%s' not found", field)
+		}
+	}
+
+	if keep != KEEP_FIRST && keep != KEEP_LAST && keep != KEEP_NONE {
+		return fmt.Errorf("duplicates error: unknown keep mode %d", keep)
+	}
+
+	return nil
+}
+
+// Returns an array of booleans corresponding in position to each row, true if the row is a duplicate
+// that is not kept by the keep mode
+func (d *Dataframe) getDuplicates(subset []string, keep keepMode) filterType {
+	if len(subset) == 0 {
+		subset = d.ColumnNames()
+	}
+
+	cols := make([]*Column, len(subset))
+	for i, field := range subset {
+		cols[i] = d.cols[field]
+	}
+
+	indices := d.getIndicesInOrder()
+	rowKeys := make([]string, len(indices))
+	counts := map[string]int{}
+	values := make([]interface{}, len(cols))
+
+	for position, row := range indices {
+		for i, col := range cols {
+			values[i] = nil
+			if col != nil && col.get(row) != nil {
+				values[i] = getDistinctKey(col.get(row))
+			}
+		}
+
+		// the Go-syntax representation keeps values of different types apart e.g. "1" and 1
+		rowKeys[position] = fmt.Sprintf("%#v", values)
+		counts[rowKeys[position]]++
+	}
+
+	flags := make(filterType, len(indices))
+	seen := make(map[string]struct{}, len(counts))
+
+	for i := range rowKeys {
+		position := i
+		if keep == KEEP_LAST {
+			position = len(rowKeys) - 1 - i
+		}
+
+		key := rowKeys[position]
+		if keep == KEEP_NONE {
+			flags[position] = counts[key] > 1
+			continue
+		}
+
+		_, flags[position] = seen[key]
+		seen[key] = struct{}{}
+	}
+
+	return flags
+}
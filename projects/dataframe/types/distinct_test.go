@@ -0,0 +1,171 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// Duplicated should mark the rows whose values for the subset were seen in an earlier row
+func TestDataframe_Duplicated(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	mixed, err := FromArray([]map[string]interface{}{
+		{"id": 1, "value": 1},
+		{"id": 2, "value": 1.0},
+		{"id": 3, "value": "1"},
+		{"id": 4, "value": nil},
+		{"id": 5, "value": nil},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	// ints beyond 2^53 cannot be told apart as float64s
+	large, err := FromArray([]map[string]interface{}{
+		{"id": 1, "value": int64(1 << 53)},
+		{"id": 2, "value": int64(1 << 53 + 1)},
+		{"id": 3, "value": int64(1 << 53)},
+		{"id": 4, "value": int64(1 << 62 + 1)},
+		{"id": 5, "value": int64(1 << 62)},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	type testRecord struct {
+		got filterType;
+		expected filterType;
+	}
+
+	testData := []testRecord{
+		{got: df.Duplicated("last name"), expected: filterType{false, true, true, false, true, true}},
+		{got: df.Duplicated("location"), expected: filterType{false, false, true, false, true, true}},
+		{got: df.Duplicated("last name", "location"), expected: filterType{false, false, true, false, true, false}},
+		{got: df.Duplicated(), expected: filterType{false, false, false, false, false, false}},
+		{got: df.Duplicated("country"), expected: filterType{false, true, true, true, true, true}},
+		{got: mixed.Duplicated("value"), expected: filterType{false, true, false, false, true}},
+		{got: large.Duplicated("value"), expected: filterType{false, false, true, false, false}},
+	}
+
+	for i, tr := range testData {
+		if len(tr.got) != len(tr.expected) {
+			t.Fatalf("record %d: expected %v, got %v", i, tr.expected, tr.got)
+		}
+
+		for j, expected := range tr.expected {
+			if tr.got[j] != expected {
+				t.Fatalf("record %d: expected %v, got %v", i, tr.expected, tr.got)
+			}
+		}
+	}
+
+	records, err := df.Select("first name").Where(df.Duplicated("last name")).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 4 || records[0]["first name"] != "Jane" || records[3]["first name"] != "Ruth" {
+		t.Fatalf("expected Jane, Paul, Reyna and Ruth, got %v", records)
+	}
+}
+
+// DropDuplicates should delete the duplicate rows, keeping the first, the last or none of them
+func TestDataframe_DropDuplicates(t *testing.T)  {
+	type testRecord struct {
+		subset []string;
+		keep keepMode;
+		expectedKeys []string;
+	}
+
+	testData := []testRecord{
+		{subset: []string{"location"}, keep: KEEP_FIRST, expectedKeys: []string{"John_Doe", "Jane_Doe", "Richard_Roe"}},
+		{subset: []string{"location"}, keep: KEEP_LAST, expectedKeys: []string{"Jane_Doe", "Reyna_Roe", "Ruth_Roe"}},
+		{subset: []string{"location"}, keep: KEEP_NONE, expectedKeys: []string{"Jane_Doe"}},
+		{subset: []string{"last name", "location"}, keep: KEEP_LAST, expectedKeys: []string{"Jane_Doe", "Paul_Doe", "Reyna_Roe", "Ruth_Roe"}},
+		{subset: nil, keep: KEEP_NONE, expectedKeys: keys},
+	}
+
+	for loop, tr := range testData {
+		df, err := FromArray(dataArray, primaryFields)
+		if err != nil {
+			t.Fatalf("loop %d, df error is: %s", loop, err)
+		}
+
+		err = df.DropDuplicates(tr.subset, tr.keep)
+		if err != nil {
+			t.Fatalf("loop %d, drop error is: %s", loop, err)
+		}
+
+		if !utils.AreStringSliceEqual(df.Keys(), tr.expectedKeys) {
+			t.Fatalf("loop %d, keys expected: %v, got %v", loop, tr.expectedKeys, df.Keys())
+		}
+	}
+
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	err = df.DropDuplicates([]string{"country"}, KEEP_FIRST)
+	if err == nil {
+		t.Fatalf("expected an error for a missing column")
+	}
+}
+
+// Distinct should keep the first row of each distinct set of values, in the order of the result
+func TestQuery_Distinct(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	type testRecord struct {
+		q *query;
+		field string;
+		expected []interface{};
+	}
+
+	testData := []testRecord{
+		{
+			q: df.Select("location").Distinct(),
+			field: "location",
+			expected: []interface{}{"Kampala", "Lusaka", "Nairobi"},
+		},
+		{
+			q: df.Select("first name", "last name").Distinct("last name"),
+			field: "first name",
+			expected: []interface{}{"John", "Richard"},
+		},
+		{
+			q: df.Select("last name").SortBy(df.Col("age").Order(DESC)).Distinct().Limit(1),
+			field: "last name",
+			expected: []interface{}{"Roe"},
+		},
+		{
+			q: df.Select("location").Where(Col("age").Lt(50)).Distinct().Offset(1),
+			field: "location",
+			expected: []interface{}{"Nairobi"},
+		},
+	}
+
+	for loop, tr := range testData {
+		records, err := tr.q.Execute()
+		if err != nil {
+			t.Fatalf("loop %d, execute error is: %s", loop, err)
+		}
+
+		if len(records) != len(tr.expected) {
+			t.Fatalf("loop %d, expected %v, got %v", loop, tr.expected, records)
+		}
+
+		for i, value := range tr.expected {
+			if records[i][tr.field] != value {
+				t.Fatalf("loop %d, expected %v, got %v", loop, tr.expected, records)
+			}
+		}
+	}
+}
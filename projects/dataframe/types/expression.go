@@ -0,0 +1,405 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	COLUMN_EXPR expressionType = iota
+	LITERAL_EXPR
+	COMPARISON_EXPR
+	ARITHMETIC_EXPR
+	LOGICAL_EXPR
+	NOT_EXPR
+)
+
+type expressionType int
+
+// Anything Where can filter rows with: a filterType computed beforehand,
+// or an expression that is evaluated against the rows when the query is executed
+type condition interface {
+	getFilter(d *Dataframe) (filterType, error)
+}
+
+// A tree of operations on columns and literal values, that is evaluated for each row when the query is executed,
+// so it always sees the current rows, and can be used on any dataframe with the columns it refers to.
+// Build it with Col and Lit, e.g. Col("age").Gt(30).And(Col("city").Eq("Kampala"))
+type expression struct {
+	_type expressionType
+	// the name of the column, for COLUMN_EXPR
+	name string
+	// the value, for LITERAL_EXPR
+	value interface{}
+	// the operator, for comparisons, arithmetic and logical operations
+	symbol string
+	operands []expression
+}
+
+// Returns the expression whose value is the value of the given column in each row
+func Col(name string) expression {
+	return expression{_type: COLUMN_EXPR, name: name}
+}
+
+// Returns the expression whose value is the given value in every row
+func Lit(value interface{}) expression {
+	return expression{_type: LITERAL_EXPR, value: value}
+}
+
+// Is true for the rows where the expression is greater than the operand, an expression or a value.
+// As for all comparisons, a comparison with nil is unknown i.e. nil, as is its negation with Not,
+// so the row is not kept by Where. Values of different types, e.g. a string and a number, are never
+// equal to, greater or less than each other
+func (e expression) Gt(operand interface{}) expression {
+	return e.binary(COMPARISON_EXPR, ">", operand)
+}
+
+// Is true for the rows where the expression is greater than or equal to the operand
+func (e expression) Gte(operand interface{}) expression {
+	return e.binary(COMPARISON_EXPR, ">=", operand)
+}
+
+// Is true for the rows where the expression is less than the operand
+func (e expression) Lt(operand interface{}) expression {
+	return e.binary(COMPARISON_EXPR, "<", operand)
+}
+
+// Is true for the rows where the expression is less than or equal to the operand
+func (e expression) Lte(operand interface{}) expression {
+	return e.binary(COMPARISON_EXPR, "<=", operand)
+}
+
+// Is true for the rows where the expression is equal to the operand. Numbers are equal if their values are,
+// whether they are ints or floats
+func (e expression) Eq(operand interface{}) expression {
+	return e.binary(COMPARISON_EXPR, "=", operand)
+}
+
+// Is true for the rows where the expression is not equal to the operand
+func (e expression) Neq(operand interface{}) expression {
+	return e.binary(COMPARISON_EXPR, "!=", operand)
+}
+
+// Is true for the rows where the expression is a string matching the pattern, and false for other values
+func (e expression) Like(pattern *regexp.Regexp) expression {
+	return e.binary(COMPARISON_EXPR, "LIKE", pattern)
+}
+
+// Adds the operand to the expression. As for all arithmetic, nil values give nil,
+// and the result is an int if both values are ints, else a float64
+func (e expression) Add(operand interface{}) expression {
+	return e.binary(ARITHMETIC_EXPR, "+", operand)
+}
+
+// Subtracts the operand from the expression
+func (e expression) Sub(operand interface{}) expression {
+	return e.binary(ARITHMETIC_EXPR, "-", operand)
+}
+
+// Multiplies the expression by the operand
+func (e expression) Mul(operand interface{}) expression {
+	return e.binary(ARITHMETIC_EXPR, "*", operand)
+}
+
+// Divides the expression by the operand, always giving a float64. Division by zero gives nil
+func (e expression) Div(operand interface{}) expression {
+	return e.binary(ARITHMETIC_EXPR, "/", operand)
+}
+
+// Is true for the rows where both the expression and the operand are true, false where either is false,
+// and unknown (nil) otherwise. The operand is not evaluated for rows where the expression is false
+func (e expression) And(operand interface{}) expression {
+	return e.binary(LOGICAL_EXPR, "AND", operand)
+}
+
+// Is true for the rows where the expression or the operand is true, false where both are false,
+// and unknown (nil) otherwise. The operand is not evaluated for rows where the expression is true
+func (e expression) Or(operand interface{}) expression {
+	return e.binary(LOGICAL_EXPR, "OR", operand)
+}
+
+// Is true for the rows where the expression is false, and unknown (nil) where the expression is unknown
+func (e expression) Not() expression {
+	return expression{_type: NOT_EXPR, symbol: "NOT", operands: []expression{e}}
+}
+
+// Describes the expression, with each operation in parentheses
+func (e expression) String() string {
+	switch e._type {
+	case COLUMN_EXPR:
+		return e.name
+	case LITERAL_EXPR:
+		if value, ok := e.value.(string); ok {
+			return fmt.Sprintf("%q", value)
+		}
+
+		return fmt.Sprintf("%v", e.value)
+	case NOT_EXPR:
+		return fmt.Sprintf("(NOT %s)", e.operands[0])
+	default:
+		return fmt.Sprintf("(%s %s %s)", e.operands[0], e.symbol, e.operands[1])
+	}
+}
+
+// Evaluates the expression for each row in order, as a filter where nil (unknown) counts as false.
+// Chunks of the rows are evaluated concurrently if the dataframe has a parallelism,
+// and the error returned is that of the first row that fails, as it is when they are evaluated serially
+func (e expression) getFilter(d *Dataframe) (filterType, error) {
+	indices := d.getIndicesInOrder()
+	filter := make(filterType, len(indices))
+
+	err := runInChunks(d.parallelism, len(indices), func(start int, end int) error {
+		for position := start; position < end; position++ {
+			value, err := e.evaluate(d, indices[position])
+			if err != nil {
+				return err
+			}
+
+			filter[position], err = toCondition(value, e)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// Returns the filter itself, as it was computed when it was created
+func (f filterType) getFilter(d *Dataframe) (filterType, error) {
+	return f, nil
+}
+
+/*
+* Helpers
+*/
+
+// Creates the expression applying the operator to this expression and the operand
+func (e expression) binary(_type expressionType, symbol string, operand interface{}) expression {
+	return expression{_type: _type, symbol: symbol, operands: []expression{e, toExpression(operand)}}
+}
+
+// Evaluates the expression for the given row of the dataframe
+func (e expression) evaluate(d *Dataframe, row int) (interface{}, error) {
+	switch e._type {
+	case COLUMN_EXPR:
+		col, ok := d.cols[e.name]
+		if !ok {
+			return nil, fmt.Errorf("filter error: column '%s' not found", e.name)
+		}
+
+		return col.get(row), nil
+	case LITERAL_EXPR:
+		return e.value, nil
+	case NOT_EXPR:
+		value, err := e.operands[0].evaluate(d, row)
+		if err != nil || value == nil {
+			return nil, err
+		}
+
+		isTrue, err := toCondition(value, e.operands[0])
+		return !isTrue, err
+	case LOGICAL_EXPR:
+		return e.evaluateLogical(d, row)
+	}
+
+	first, err := e.operands[0].evaluate(d, row)
+	if err != nil {
+		return nil, err
+	}
+
+	second, err := e.operands[1].evaluate(d, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if e._type == COMPARISON_EXPR {
+		if first == nil || second == nil {
+			return nil, nil
+		}
+
+		return compareForExpression(e.symbol, first, second), nil
+	}
+
+	return e.evaluateArithmetic(first, second)
+}
+
+// Evaluates AND and OR with three-valued logic, nil being unknown,
+// evaluating the second operand only if the first does not decide the result
+func (e expression) evaluateLogical(d *Dataframe, row int) (interface{}, error) {
+	// the value that decides the result: false for AND, true for OR
+	decisive := e.symbol == "OR"
+
+	first, err := e.operands[0].evaluate(d, row)
+	if err != nil {
+		return nil, err
+	}
+
+	isFirstTrue, err := toCondition(first, e.operands[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if first != nil && isFirstTrue == decisive {
+		return decisive, nil
+	}
+
+	second, err := e.operands[1].evaluate(d, row)
+	if err != nil {
+		return nil, err
+	}
+
+	isSecondTrue, err := toCondition(second, e.operands[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if second != nil && isSecondTrue == decisive {
+		return decisive, nil
+	}
+
+	if first == nil || second == nil {
+		return nil, nil
+	}
+
+	return !decisive, nil
+}
+
+// Applies the arithmetic operator to the two values
+func (e expression) evaluateArithmetic(first interface{}, second interface{}) (interface{}, error) {
+	if first == nil || second == nil {
+		return nil, nil
+	}
+
+	firstNumber, isFirstNumber := toFloat64(first)
+	secondNumber, isSecondNumber := toFloat64(second)
+	if !isFirstNumber || !isSecondNumber {
+		return nil, fmt.Errorf("filter error: %s cannot be applied to %v (%T) and %v (%T) in %s", e.symbol, first, first, second, second, e)
+	}
+
+	firstInt, isFirstInt := first.(int)
+	secondInt, isSecondInt := second.(int)
+	areInts := isFirstInt && isSecondInt
+
+	switch e.symbol {
+	case "+":
+		if areInts {
+			return firstInt + secondInt, nil
+		}
+		return firstNumber + secondNumber, nil
+	case "-":
+		if areInts {
+			return firstInt - secondInt, nil
+		}
+		return firstNumber - secondNumber, nil
+	case "*":
+		if areInts {
+			return firstInt * secondInt, nil
+		}
+		return firstNumber * secondNumber, nil
+	default:
+		if secondNumber == 0 {
+			return nil, nil
+		}
+		return firstNumber / secondNumber, nil
+	}
+}
+
+// Compares the two values, neither of which is nil, with the comparison operator.
+// As with the predicates of columns, values of different types are never equal to, greater or less than each other
+func compareForExpression(symbol string, first interface{}, second interface{}) bool {
+	if pattern, isPattern := second.(*regexp.Regexp); isPattern && symbol == "LIKE" {
+		str, isStr := first.(string)
+		return isStr && pattern.MatchString(str)
+	}
+
+	if getValueKind(first) != getValueKind(second) {
+		return false
+	}
+
+	// bools are only equal or not equal
+	_, isFirstBool := first.(bool)
+	_, isSecondBool := second.(bool)
+	if isFirstBool || isSecondBool {
+		switch symbol {
+		case "=":
+			return first == second
+		case "!=":
+			return first != second
+		default:
+			return false
+		}
+	}
+
+	result := compareValues(first, second)
+
+	switch symbol {
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case "=":
+		return result == 0
+	default:
+		return result != 0
+	}
+}
+
+// Returns the kind of the value as far as comparisons go: "number", "string", "time", "bool",
+// or else the name of its type
+func getValueKind(value interface{}) string {
+	if _, isNumber := toFloat64(value); isNumber {
+		return "number"
+	}
+
+	switch value.(type) {
+	case string:
+		return "string"
+	case time.Time:
+		return "time"
+	case bool:
+		return "bool"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+// Converts the value of the expression into a condition: nil is false, and anything other than a bool is an error
+func toCondition(value interface{}, e expression) (bool, error) {
+	switch value := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return value, nil
+	}
+
+	return false, fmt.Errorf("filter error: %s gives %v (%T), not a bool", e, value, value)
+}
+
+// Wraps values that are not expressions in literal expressions
+func toExpression(operand interface{}) expression {
+	if e, ok := operand.(expression); ok {
+		return e
+	}
+
+	return Lit(operand)
+}
+
+// Describes the condition for Explain
+func describeCondition(c condition) string {
+	if e, ok := c.(expression); ok {
+		return e.String()
+	}
+
+	return "<mask>"
+}
@@ -0,0 +1,156 @@
+package types
+
+import (
+	"testing"
+)
+
+// Expressions should compare columns to values and to other columns, compute arithmetic and combine conditions
+func TestExpression_getFilter(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	type testRecord struct {
+		expr expression;
+		expected []string;
+	}
+
+	testData := []testRecord{
+		{expr: Col("age").Gt(30).And(Col("location").Eq("Kampala")), expected: []string{"Ruth"}},
+		{expr: Col("age").Lt(20).Or(Col("first name").Eq("Jane")), expected: []string{"Jane", "Paul"}},
+		{expr: Col("age").Mul(2).Sub(Col("age")).Eq(Col("age")), expected: []string{"John", "Jane", "Paul", "Richard", "Reyna", "Ruth"}},
+		{expr: Col("age").Div(10).Gte(4.5), expected: []string{"Jane", "Reyna", "Ruth"}},
+		{expr: Col("age").Add(1).Neq(31).Not(), expected: []string{"John"}},
+		{expr: Col("age").Lte(30.0).And(Col("age").Gte(30)), expected: []string{"John"}},
+		{expr: Col("first name").Lt(Col("last name")), expected: []string{"Richard", "Reyna"}},
+		{expr: Col("age").Add(Lit(nil)).Gt(0), expected: []string{}},
+		// the second operands would fail, but are never evaluated
+		{expr: Col("age").Gt(100).And(Col("salary").Eq(1)), expected: []string{}},
+		{expr: Col("age").Gt(0).Or(Col("first name").Add(1).Gt(1)), expected: []string{"John", "Jane", "Paul", "Richard", "Reyna", "Ruth"}},
+	}
+
+	for loop, tr := range testData {
+		records, err := df.Select("first name").Where(tr.expr).Execute()
+		if err != nil {
+			t.Fatalf("loop %d, %s: execute error is: %s", loop, tr.expr, err)
+		}
+
+		if len(records) != len(tr.expected) {
+			t.Fatalf("loop %d, %s: expected %v, got %v", loop, tr.expr, tr.expected, records)
+		}
+
+		for i, name := range tr.expected {
+			if records[i]["first name"] != name {
+				t.Fatalf("loop %d, %s: expected %v, got %v", loop, tr.expr, tr.expected, records)
+			}
+		}
+	}
+
+	for loop, expr := range []expression{
+		Col("salary").Eq(1),
+		Col("first name").Add(1).Gt(1),
+		Col("age"),
+	} {
+		_, err := df.Select().Where(expr).Execute()
+		if err == nil {
+			t.Fatalf("loop %d, expected an error for %s", loop, expr)
+		}
+	}
+}
+
+// Comparisons with nil should be unknown, even when negated, and values of different types should never compare
+func TestExpression_NullsAndTypes(t *testing.T)  {
+	df, err := FromArray([]map[string]interface{}{
+		{"id": 1, "x": 1, "code": "9"},
+		{"id": 2, "x": 2, "code": "10"},
+		{"id": 3, "x": nil, "code": "x"},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	type testRecord struct {
+		expr expression;
+		expected []int;
+	}
+
+	testData := []testRecord{
+		{expr: Col("x").Eq(1).Not(), expected: []int{2}},
+		{expr: Col("x").Gt(1).Not(), expected: []int{1}},
+		{expr: Col("x").Eq(1).Or(Col("code").Eq("x")), expected: []int{1, 3}},
+		{expr: Col("x").Eq(1).And(Col("code").Eq("x")).Not(), expected: []int{1, 2}},
+		{expr: Col("x").Gt(0).Or(Col("code").Eq("x")).Not(), expected: []int{}},
+		{expr: Col("x").Gt(5).And(Col("code").Eq("y")).Not(), expected: []int{1, 2, 3}},
+		{expr: Col("code").Gt(10), expected: []int{}},
+		{expr: Col("code").Eq(9), expected: []int{}},
+		{expr: Col("code").Gt("10"), expected: []int{1, 3}},
+	}
+
+	for loop, tr := range testData {
+		records, err := df.Select("id").Where(tr.expr).Execute()
+		if err != nil {
+			t.Fatalf("loop %d, %s: execute error is: %s", loop, tr.expr, err)
+		}
+
+		if len(records) != len(tr.expected) {
+			t.Fatalf("loop %d, %s: expected %v, got %v", loop, tr.expr, tr.expected, records)
+		}
+
+		for i, id := range tr.expected {
+			if records[i]["id"] != id {
+				t.Fatalf("loop %d, %s: expected %v, got %v", loop, tr.expr, tr.expected, records)
+			}
+		}
+	}
+}
+
+// Expressions should be evaluated when the query is executed, so they see rows inserted after the query was built,
+// and should work on any dataframe with the columns they refer to
+func TestExpression_EvaluatedOnExecute(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	isOld := Col("age").Gt(55)
+	q := df.Select("first name").Where(isOld)
+
+	err = df.Insert([]map[string]interface{}{
+		{"first name": "Tom", "last name": "Poe", "age": 70, "location": "Kampala"},
+	})
+	if err != nil {
+		t.Fatalf("insert error is: %s", err)
+	}
+
+	records, err := q.Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 2 || records[0]["first name"] != "Ruth" || records[1]["first name"] != "Tom" {
+		t.Fatalf("expected Ruth and Tom, got %v", records)
+	}
+
+	other, err := FromArray([]map[string]interface{}{
+		{"first name": "Ann", "age": 80.5},
+		{"first name": "Bob", "age": 20.0},
+	}, []string{"first name"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	records, err = other.Select("first name").Where(isOld).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 1 || records[0]["first name"] != "Ann" {
+		t.Fatalf("expected Ann, got %v", records)
+	}
+
+	expectedDescription := `((age > 55) AND (location = "Kampala"))`
+	if description := isOld.And(Col("location").Eq("Kampala")).String(); description != expectedDescription {
+		t.Fatalf("expected %s, got %s", expectedDescription, description)
+	}
+}
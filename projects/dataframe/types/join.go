@@ -0,0 +1,302 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+const (
+	// keeps only the rows that have matches in both dataframes
+	INNER_JOIN joinType = iota
+	// keeps all rows of the left dataframe, with nils where the right dataframe has no match
+	LEFT_JOIN
+	// keeps all rows of the right dataframe, with nils where the left dataframe has no match
+	RIGHT_JOIN
+	// keeps all rows of both dataframes, with nils where either has no match
+	OUTER_JOIN
+	// keeps only the rows of the left dataframe that have a match in the right one, and only the left columns
+	SEMI_JOIN
+	// keeps only the rows of the left dataframe that have no match in the right one, and only the left columns
+	ANTI_JOIN
+)
+
+type joinType int
+
+// Joins this dataframe (the left) with the other dataframe (the right) on the given key columns,
+// and returns the result as a new Dataframe. Rows whose keys have nil values never match.
+// Other columns found in both dataframes are renamed using the suffixes, which default to "_x" for the left
+// and "_y" for the right. The primary fields of the result are those of both dataframes.
+// It is a hash join; the right dataframe is hashed
+func (d *Dataframe) Join(other *Dataframe, on []string, how joinType, suffixes ...string) (*Dataframe, error) {
+	if len(on) == 0 {
+		return nil, fmt.Errorf("join error: no key columns given")
+	}
+
+	for _, field := range on {
+		if _, ok := d.cols[field]; !ok {
+			return nil, fmt.Errorf("join error: key column '%s' not found in the left dataframe", field)
+		}
+
+		if _, ok := other.cols[field]; !ok {
+			return nil, fmt.Errorf("join error: key column '%s' not found in the right dataframe", field)
+		}
+	}
+
+	leftSuffix, rightSuffix := "_x", "_y"
+	if len(suffixes) > 0 {
+		leftSuffix = suffixes[0]
+	}
+	if len(suffixes) > 1 {
+		rightSuffix = suffixes[1]
+	}
+
+	leftRows, rightRows := d.matchRows(other, on, how)
+
+	if how == SEMI_JOIN || how == ANTI_JOIN {
+		newDf := Dataframe{
+			pkFields: d.pkFields,
+			cols: make(map[string]*Column, len(d.cols)),
+			index: make(map[interface{}]int, len(leftRows)),
+			strictness: d.strictness,
+		}
+
+		for name, col := range d.cols {
+			newDf.cols[name] = col.gather(name, leftRows)
+		}
+
+		err := newDf.buildIndex()
+		if err != nil {
+			return nil, err
+		}
+
+		return &newDf, nil
+	}
+
+	onMap := make(map[string]struct{}, len(on))
+	for _, field := range on {
+		onMap[field] = struct{}{}
+	}
+
+	leftNames := map[string]string{}
+	rightNames := map[string]string{}
+
+	for name := range d.cols {
+		_, isKey := onMap[name]
+		_, isShared := other.cols[name]
+
+		if !isKey && isShared {
+			leftNames[name] = name + leftSuffix
+		} else {
+			leftNames[name] = name
+		}
+	}
+
+	for name := range other.cols {
+		_, isKey := onMap[name]
+		_, isShared := d.cols[name]
+
+		if !isKey && isShared {
+			rightNames[name] = name + rightSuffix
+		} else if !isKey {
+			rightNames[name] = name
+		}
+	}
+
+	pkFields := appendRenamedFields(nil, d.pkFields, leftNames)
+	pkFields = appendRenamedFields(pkFields, other.pkFields, rightNames)
+
+	newDf := Dataframe{
+		pkFields: pkFields,
+		cols: make(map[string]*Column, len(leftNames)+len(rightNames)),
+		index: make(map[interface{}]int, len(leftRows)),
+		strictness: d.strictness,
+	}
+
+	for name, newName := range leftNames {
+		// FIXME: concurrency possible as the columns are independent
+		newDf.cols[newName] = d.cols[name].gather(newName, leftRows)
+	}
+
+	for name, newName := range rightNames {
+		// FIXME: concurrency possible as the columns are independent
+		newDf.cols[newName] = other.cols[name].gather(newName, rightRows)
+	}
+
+	// the key columns take their values from the right dataframe where the left has no match
+	for _, field := range on {
+		col := newDf.cols[field]
+		rightCol := other.cols[field]
+
+		for i, leftRow := range leftRows {
+			if leftRow < 0 && rightRows[i] >= 0 {
+				col.insert(i, rightCol.get(rightRows[i]))
+			}
+		}
+	}
+
+	err := newDf.buildIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	return &newDf, nil
+}
+
+/*
+* Helpers
+*/
+
+// Matches the rows of this dataframe with those of the other basing on the values of the key columns,
+// returning the pairs of left and right rows that make up the joined rows. A row with no match is -1
+func (d *Dataframe) matchRows(other *Dataframe, on []string, how joinType) ([]int, []int) {
+	leftKeys := getJoinKeys(d, on)
+	rightKeys := getJoinKeys(other, on)
+	hashTable := make(map[string][]int, len(rightKeys))
+	leftRows := []int{}
+	rightRows := []int{}
+
+	for row, key := range rightKeys {
+		if key != nil {
+			hashTable[*key] = append(hashTable[*key], row)
+		}
+	}
+
+	isRightMatched := make([]bool, len(rightKeys))
+
+	for row, key := range leftKeys {
+		var matches []int
+		if key != nil {
+			matches = hashTable[*key]
+		}
+
+		switch how {
+		case SEMI_JOIN:
+			if len(matches) > 0 {
+				leftRows = append(leftRows, row)
+			}
+			continue
+		case ANTI_JOIN:
+			if len(matches) == 0 {
+				leftRows = append(leftRows, row)
+			}
+			continue
+		}
+
+		for _, match := range matches {
+			leftRows = append(leftRows, row)
+			rightRows = append(rightRows, match)
+			isRightMatched[match] = true
+		}
+
+		if len(matches) == 0 && (how == LEFT_JOIN || how == OUTER_JOIN) {
+			leftRows = append(leftRows, row)
+			rightRows = append(rightRows, -1)
+		}
+	}
+
+	if how == RIGHT_JOIN || how == OUTER_JOIN {
+		for row, isMatched := range isRightMatched {
+			if !isMatched {
+				leftRows = append(leftRows, -1)
+				rightRows = append(rightRows, row)
+			}
+		}
+	}
+
+	if how == RIGHT_JOIN {
+		// put the rows back in the order of the right dataframe
+		leftRows, rightRows = orderByRight(leftRows, rightRows, len(rightKeys))
+	}
+
+	return leftRows, rightRows
+}
+
+// Reorders the pairs of left and right rows in the order of the right rows, keeping the order of the left rows
+// for the same right row
+func orderByRight(leftRows []int, rightRows []int, rightCount int) ([]int, []int) {
+	positions := make([][]int, rightCount)
+	for i, row := range rightRows {
+		positions[row] = append(positions[row], i)
+	}
+
+	orderedLeft := make([]int, 0, len(leftRows))
+	orderedRight := make([]int, 0, len(rightRows))
+
+	for _, rowPositions := range positions {
+		for _, i := range rowPositions {
+			orderedLeft = append(orderedLeft, leftRows[i])
+			orderedRight = append(orderedRight, rightRows[i])
+		}
+	}
+
+	return orderedLeft, orderedRight
+}
+
+// Returns the hash keys of the rows of the dataframe made up of the values of the given columns, in row order.
+// The keys are typed as in DropDuplicates, so that e.g. the int 1 and the string "1" do not match.
+// Rows that have a nil in any of the key columns get a nil key, so they match no other row
+func getJoinKeys(d *Dataframe, on []string) []*string {
+	count := d.Count()
+	keys := make([]*string, count)
+	cols := make([]*Column, len(on))
+	values := make([]interface{}, len(on))
+
+	for i, field := range on {
+		cols[i] = d.cols[field]
+	}
+
+	for row := 0; row < count; row++ {
+		hasNil := false
+
+		for i, col := range cols {
+			value := col.get(row)
+			if isNilValue(value) {
+				hasNil = true
+				break
+			}
+
+			values[i] = getDistinctKey(value)
+		}
+
+		if !hasNil {
+			// the Go-syntax representation keeps values of different types apart e.g. "1" and 1
+			key := fmt.Sprintf("%#v", values)
+			keys[row] = &key
+		}
+	}
+
+	return keys
+}
+
+// Returns whether the value is nil, or a nil pointer, map, slice, channel, function or interface
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// Appends the new names of the fields to the list of names, if not already in it.
+// Fields that are not renamed keep their names
+func appendRenamedFields(names []string, fields []string, renames map[string]string) []string {
+	for _, field := range fields {
+		name, ok := renames[field]
+		if !ok {
+			name = field
+		}
+
+		if !utils.ContainsString(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
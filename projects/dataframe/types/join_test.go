@@ -0,0 +1,242 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// Join should combine the rows of two dataframes that have matching keys, basing on the join type
+func TestDataframe_Join(t *testing.T)  {
+	people, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("people error is: %s", err)
+	}
+
+	cities, err := FromArray([]map[string]interface{}{
+		{"location": "Nairobi", "country": "Kenya", "age": 120},
+		{"location": "Kampala", "country": "Uganda", "age": 130},
+		{"location": "Dodoma", "country": "Tanzania", "age": 50},
+		{"location": nil, "country": "Nowhere", "age": 0},
+	}, []string{"country"})
+	if err != nil {
+		t.Fatalf("cities error is: %s", err)
+	}
+
+	type testRecord struct {
+		how joinType;
+		expectedPkFields []string;
+		expected []map[string]interface{};
+	}
+
+	testData := []testRecord{
+		{
+			how: INNER_JOIN,
+			expectedPkFields: []string{"first name", "last name", "country"},
+			expected: []map[string]interface{}{
+				{"first name": "John", "last name": "Doe", "age_x": 30, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": "Paul", "last name": "Doe", "age_x": 19, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": "Richard", "last name": "Roe", "age_x": 34, "location": "Nairobi", "country": "Kenya", "age_y": 120},
+				{"first name": "Reyna", "last name": "Roe", "age_x": 45, "location": "Nairobi", "country": "Kenya", "age_y": 120},
+				{"first name": "Ruth", "last name": "Roe", "age_x": 60, "location": "Kampala", "country": "Uganda", "age_y": 130},
+			},
+		},
+		{
+			how: LEFT_JOIN,
+			expectedPkFields: []string{"first name", "last name", "country"},
+			expected: []map[string]interface{}{
+				{"first name": "John", "last name": "Doe", "age_x": 30, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": "Jane", "last name": "Doe", "age_x": 50, "location": "Lusaka", "country": nil, "age_y": nil},
+				{"first name": "Paul", "last name": "Doe", "age_x": 19, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": "Richard", "last name": "Roe", "age_x": 34, "location": "Nairobi", "country": "Kenya", "age_y": 120},
+				{"first name": "Reyna", "last name": "Roe", "age_x": 45, "location": "Nairobi", "country": "Kenya", "age_y": 120},
+				{"first name": "Ruth", "last name": "Roe", "age_x": 60, "location": "Kampala", "country": "Uganda", "age_y": 130},
+			},
+		},
+		{
+			how: RIGHT_JOIN,
+			expectedPkFields: []string{"first name", "last name", "country"},
+			expected: []map[string]interface{}{
+				{"first name": "Richard", "last name": "Roe", "age_x": 34, "location": "Nairobi", "country": "Kenya", "age_y": 120},
+				{"first name": "Reyna", "last name": "Roe", "age_x": 45, "location": "Nairobi", "country": "Kenya", "age_y": 120},
+				{"first name": "John", "last name": "Doe", "age_x": 30, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": "Paul", "last name": "Doe", "age_x": 19, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": "Ruth", "last name": "Roe", "age_x": 60, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": nil, "last name": nil, "age_x": nil, "location": "Dodoma", "country": "Tanzania", "age_y": 50},
+				{"first name": nil, "last name": nil, "age_x": nil, "location": nil, "country": "Nowhere", "age_y": 0},
+			},
+		},
+		{
+			how: OUTER_JOIN,
+			expectedPkFields: []string{"first name", "last name", "country"},
+			expected: []map[string]interface{}{
+				{"first name": "John", "last name": "Doe", "age_x": 30, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": "Jane", "last name": "Doe", "age_x": 50, "location": "Lusaka", "country": nil, "age_y": nil},
+				{"first name": "Paul", "last name": "Doe", "age_x": 19, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": "Richard", "last name": "Roe", "age_x": 34, "location": "Nairobi", "country": "Kenya", "age_y": 120},
+				{"first name": "Reyna", "last name": "Roe", "age_x": 45, "location": "Nairobi", "country": "Kenya", "age_y": 120},
+				{"first name": "Ruth", "last name": "Roe", "age_x": 60, "location": "Kampala", "country": "Uganda", "age_y": 130},
+				{"first name": nil, "last name": nil, "age_x": nil, "location": "Dodoma", "country": "Tanzania", "age_y": 50},
+				{"first name": nil, "last name": nil, "age_x": nil, "location": nil, "country": "Nowhere", "age_y": 0},
+			},
+		},
+		{
+			how: SEMI_JOIN,
+			expectedPkFields: primaryFields,
+			expected: []map[string]interface{}{
+				{"first name": "John", "last name": "Doe", "age": 30, "location": "Kampala"},
+				{"first name": "Paul", "last name": "Doe", "age": 19, "location": "Kampala"},
+				{"first name": "Richard", "last name": "Roe", "age": 34, "location": "Nairobi"},
+				{"first name": "Reyna", "last name": "Roe", "age": 45, "location": "Nairobi"},
+				{"first name": "Ruth", "last name": "Roe", "age": 60, "location": "Kampala"},
+			},
+		},
+		{
+			how: ANTI_JOIN,
+			expectedPkFields: primaryFields,
+			expected: []map[string]interface{}{
+				{"first name": "Jane", "last name": "Doe", "age": 50, "location": "Lusaka"},
+			},
+		},
+	}
+
+	for loop, tr := range testData {
+		joined, err := people.Join(cities, []string{"location"}, tr.how)
+		if err != nil {
+			t.Fatalf("loop %d, join error is: %s", loop, err)
+		}
+
+		if !utils.AreStringSliceEqual(joined.pkFields, tr.expectedPkFields){
+			t.Fatalf("loop %d, pkFields expected: %v, got %v", loop, tr.expectedPkFields, joined.pkFields)
+		}
+
+		records, err := joined.ToArray()
+		if err != nil {
+			t.Fatalf("error on ToArray is: %s", err)
+		}
+
+		if len(records) != len(tr.expected) {
+			t.Fatalf("loop %d, expected number of records: %d, got %d", loop, len(tr.expected), len(records))
+		}
+
+		for i, record := range tr.expected {
+			if len(records[i]) != len(record) {
+				t.Fatalf("loop %d, the record %d expected %v, got %v", loop, i, record, records[i])
+			}
+
+			for field, expectedValue := range record {
+				if expectedValue != records[i][field] {
+					t.Fatalf("loop %d, the record %d expected %v, got %v", loop, i, record, records[i])
+				}
+			}
+		}
+	}
+}
+
+// Join should use the given suffixes for conflicting names, and reject keys missing in either dataframe
+func TestDataframe_JoinOptions(t *testing.T)  {
+	left, err := FromArray([]map[string]interface{}{{"id": 1, "x": "a"}, {"id": 2, "x": "b"}}, []string{"id"})
+	if err != nil {
+		t.Fatalf("left error is: %s", err)
+	}
+
+	right, err := FromArray([]map[string]interface{}{{"id": 2, "x": "c"}}, []string{"id"})
+	if err != nil {
+		t.Fatalf("right error is: %s", err)
+	}
+
+	joined, err := left.Join(right, []string{"id"}, INNER_JOIN, "_left", "_right")
+	if err != nil {
+		t.Fatalf("join error is: %s", err)
+	}
+
+	expectedCols := []string{"id", "x_left", "x_right"}
+	colNames := utils.SortStringSlice(joined.ColumnNames(), utils.ASC)
+	if !utils.AreStringSliceEqual(colNames, expectedCols){
+		t.Fatalf("cols expected: %v, got: %v", expectedCols, colNames)
+	}
+
+	if !utils.AreStringSliceEqual(joined.Keys(), []string{"2"}) {
+		t.Fatalf("keys expected: %v, got: %v", []string{"2"}, joined.Keys())
+	}
+
+	_, err = left.Join(right, []string{"y"}, INNER_JOIN)
+	if err == nil {
+		t.Fatalf("expected an error for a missing key column")
+	}
+}
+
+// Join should only match keys of the same type, and never match nil keys
+func TestDataframe_JoinKeyTypes(t *testing.T)  {
+	left, err := FromArray([]map[string]interface{}{
+		{"id": 1, "code": 1},
+		{"id": 2, "code": nil},
+		{"id": 3, "code": "x"},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("left error is: %s", err)
+	}
+
+	right, err := FromArray([]map[string]interface{}{
+		{"rid": 1, "code": "1"},
+		{"rid": 2, "code": "<nil>"},
+		{"rid": 3, "code": "x"},
+		{"rid": 4, "code": 1.0},
+		{"rid": 5, "code": nil},
+	}, []string{"rid"})
+	if err != nil {
+		t.Fatalf("right error is: %s", err)
+	}
+
+	joined, err := left.Join(right, []string{"code"}, INNER_JOIN)
+	if err != nil {
+		t.Fatalf("join error is: %s", err)
+	}
+
+	records, err := joined.ToArray()
+	if err != nil {
+		t.Fatalf("error on ToArray is: %s", err)
+	}
+
+	expected := [][2]int{{1, 4}, {3, 3}}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %v", len(expected), records)
+	}
+
+	for i, pair := range expected {
+		if records[i]["id"] != pair[0] || records[i]["rid"] != pair[1] {
+			t.Fatalf("record %d: expected id %d and rid %d, got %v", i, pair[0], pair[1], records[i])
+		}
+	}
+
+	// ints beyond 2^53 match only when they are equal
+	users, err := FromArray([]map[string]interface{}{
+		{"user": int64(1 << 53), "name": "a"},
+		{"user": int64(1 << 53 + 1), "name": "b"},
+	}, []string{"user"})
+	if err != nil {
+		t.Fatalf("users error is: %s", err)
+	}
+
+	orders, err := FromArray([]map[string]interface{}{
+		{"order": 1, "user": int64(1 << 53 + 1)},
+		{"order": 2, "user": int64(1 << 53 + 3)},
+	}, []string{"order"})
+	if err != nil {
+		t.Fatalf("orders error is: %s", err)
+	}
+
+	joined, err = users.Join(orders, []string{"user"}, INNER_JOIN)
+	if err != nil {
+		t.Fatalf("join error is: %s", err)
+	}
+
+	records, err = joined.ToArray()
+	if err != nil {
+		t.Fatalf("error on ToArray is: %s", err)
+	}
+
+	if len(records) != 1 || records[0]["name"] != "b" || records[0]["order"] != 1 {
+		t.Fatalf("expected only user b to match order 1, got %v", records)
+	}
+}
@@ -0,0 +1,240 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	// nil values are written as JSON null
+	NIL_AS_NULL nilEncoding = iota
+	// fields with nil values are left out of the record
+	NIL_OMITTED
+	// nil values are replaced with JSONOptions.NilValue
+	NIL_AS_VALUE
+)
+
+type nilEncoding int
+
+// Options used when writing a Dataframe out as JSON or NDJSON
+type JSONOptions struct {
+	// The columns to write. They default to all the columns
+	Columns []string
+	// The prefix to start each line with. It is ignored for NDJSON
+	Prefix string
+	// The indentation for each level. If empty, the output is compact. It is ignored for NDJSON
+	Indent string
+	// How nil values are encoded. It defaults to NIL_AS_NULL
+	Nil nilEncoding
+	// The value written in place of nil when Nil is NIL_AS_VALUE
+	NilValue interface{}
+}
+
+// Constructs a Dataframe from a JSON array of objects read from r and returns a pointer to it.
+// The records are decoded and inserted one at a time
+func FromJSON(r io.Reader, primaryFields []string) (*Dataframe, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("json error: expected an array of records, got %v", token)
+	}
+
+	df := Dataframe{
+		pkFields: primaryFields,
+		cols: map[string]*Column{},
+		index: map[interface{}]int{},
+	}
+
+	for decoder.More() {
+		err = df.decodeRecord(decoder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	df.normalizeCols(nil)
+	return &df, nil
+}
+
+// Constructs a Dataframe from newline-delimited JSON objects read from r and returns a pointer to it.
+// The records are decoded and inserted one at a time
+func FromNDJSON(r io.Reader, primaryFields []string) (*Dataframe, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	df := Dataframe{
+		pkFields: primaryFields,
+		cols: map[string]*Column{},
+		index: map[interface{}]int{},
+	}
+
+	for {
+		err := df.decodeRecord(decoder)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	df.normalizeCols(nil)
+	return &df, nil
+}
+
+// Writes the records of the dataframe to w as a JSON array of objects, one record at a time
+func (d *Dataframe) WriteJSON(w io.Writer, opts JSONOptions) error {
+	fields := opts.Columns
+	if fields == nil {
+		fields = d.ColumnNames()
+	}
+
+	lineStart := ""
+	closing := "]\n"
+	if opts.Indent != "" {
+		lineStart = "\n" + opts.Prefix + opts.Indent
+		closing = "\n" + opts.Prefix + "]\n"
+	}
+
+	_, err := io.WriteString(w, "[")
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = d.iterRows(fields, func(row []interface{}) error {
+		data, err := marshalRecord(fields, row, opts, opts.Prefix+opts.Indent)
+		if err != nil {
+			return err
+		}
+
+		separator := lineStart
+		if count > 0 {
+			separator = "," + lineStart
+		}
+		count++
+
+		_, err = io.WriteString(w, separator)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		closing = "]\n"
+	}
+
+	_, err = io.WriteString(w, closing)
+	return err
+}
+
+// Writes the records of the dataframe to w as newline-delimited JSON objects, one record at a time
+func (d *Dataframe) WriteNDJSON(w io.Writer, opts JSONOptions) error {
+	fields := opts.Columns
+	if fields == nil {
+		fields = d.ColumnNames()
+	}
+
+	opts.Indent = ""
+	return d.iterRows(fields, func(row []interface{}) error {
+		data, err := marshalRecord(fields, row, opts, "")
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+/*
+* Helpers
+*/
+
+// Decodes the next record from the decoder and inserts it into the dataframe
+func (d *Dataframe) decodeRecord(decoder *json.Decoder) error {
+	record := map[string]interface{}{}
+
+	err := decoder.Decode(&record)
+	if err != nil {
+		return err
+	}
+
+	for field, value := range record {
+		record[field] = normalizeJSONValue(value)
+	}
+
+	return d.insertRecord(record)
+}
+
+// Converts the json.Number values, even those nested in arrays and objects, to int or float64
+func normalizeJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		}
+
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONValue(item)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONValue(item)
+		}
+	}
+
+	return value
+}
+
+// Marshals a single row into a JSON object, encoding nil values as the options require
+func marshalRecord(fields []string, row []interface{}, opts JSONOptions, prefix string) ([]byte, error) {
+	record := make(map[string]interface{}, len(fields))
+
+	for i, field := range fields {
+		value := row[i]
+
+		if value == nil {
+			switch opts.Nil {
+			case NIL_OMITTED:
+				continue
+			case NIL_AS_VALUE:
+				value = opts.NilValue
+			}
+		}
+
+		record[field] = value
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil || opts.Indent == "" {
+		return data, err
+	}
+
+	var indented bytes.Buffer
+	err = json.Indent(&indented, data, prefix, opts.Indent)
+	return indented.Bytes(), err
+}
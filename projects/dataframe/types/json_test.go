@@ -0,0 +1,138 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// FromJSON should create a dataframe from a JSON array of objects, decoding integral numbers as int
+func TestFromJSON(t *testing.T)  {
+	data := `[
+		{"first name": "John", "last name": "Doe", "age": 30, "location": "Kampala"},
+		{"first name": "Jane", "last name": "Doe", "age": 50, "location": "Lusaka"},
+		{"first name": "Paul", "last name": "Doe", "age": 19, "location": "Kampala"},
+		{"first name": "Richard", "last name": "Roe", "age": 34, "location": "Nairobi"},
+		{"first name": "Reyna", "last name": "Roe", "age": 45, "location": "Nairobi"},
+		{"first name": "Ruth", "last name": "Roe", "age": 60, "location": "Kampala"}
+	]`
+
+	df, err := FromJSON(strings.NewReader(data), primaryFields)
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	if !utils.AreStringSliceEqual(keys, df.Keys()) {
+		t.Fatalf("keys expected: %v, got: %v", keys, df.Keys())
+	}
+
+	for _, col := range df.cols {
+		expectedItems := utils.ExtractFieldFromMapList(dataArray, col.Name)
+		if !utils.AreSliceEqual(col.Items(), expectedItems){
+			t.Fatalf("col '%s' items expected: %v, got %v", col.Name, expectedItems, col.Items())
+		}
+	}
+
+	_, err = FromJSON(strings.NewReader(`{"age": 30}`), primaryFields)
+	if err == nil {
+		t.Fatalf("expected an error for a JSON object that is not an array")
+	}
+}
+
+// FromNDJSON should create a dataframe from newline-delimited JSON objects, filling missing fields with nil
+func TestFromNDJSON(t *testing.T)  {
+	data := `{"id": 1, "score": 2.5, "tags": [1, 2]}
+{"id": 2, "active": true}
+
+{"id": 1, "score": 4}
+`
+
+	df, err := FromNDJSON(strings.NewReader(data), []string{"id"})
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	expected := map[string][]interface{}{
+		"id": {1, 2},
+		// the score column is inferred as FloatType from 2.5, so the int is widened
+		"score": {4.0, nil},
+		"active": {nil, true},
+	}
+
+	for field, expectedItems := range expected {
+		if !utils.AreSliceEqual(df.Col(field).Items(), expectedItems){
+			t.Fatalf("col '%s' items expected: %v, got %v", field, expectedItems, df.Col(field).Items())
+		}
+	}
+
+	tags := df.Col("tags").Items()[0].([]interface{})
+	if !utils.AreSliceEqual(tags, []interface{}{1, 2}) {
+		t.Fatalf("nested numbers expected: %v, got %v", []interface{}{1, 2}, tags)
+	}
+}
+
+// WriteJSON and WriteNDJSON should stream the records, honouring the column subset, indentation and nil encoding
+func TestDataframe_WriteJSON(t *testing.T)  {
+	df, err := FromArray([]map[string]interface{}{
+		{"id": 1, "name": "John", "score": 2.5},
+		{"id": 2, "name": "Jane", "score": nil},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	empty, err := FromArray([]map[string]interface{}{}, []string{"id"})
+	if err != nil {
+		t.Fatalf("empty df error is: %s", err)
+	}
+
+	type testRecord struct {
+		df *Dataframe;
+		isNDJSON bool;
+		opts JSONOptions;
+		expected string;
+	}
+
+	testData := []testRecord{
+		{
+			df: df,
+			opts: JSONOptions{},
+			expected: `[{"id":1,"name":"John","score":2.5},{"id":2,"name":"Jane","score":null}]` + "\n",
+		},
+		{
+			df: df,
+			opts: JSONOptions{Columns: []string{"id", "score"}, Indent: "  ", Nil: NIL_OMITTED},
+			expected: "[\n  {\n    \"id\": 1,\n    \"score\": 2.5\n  },\n  {\n    \"id\": 2\n  }\n]\n",
+		},
+		{
+			df: df,
+			isNDJSON: true,
+			opts: JSONOptions{Columns: []string{"name", "score"}, Indent: "  ", Nil: NIL_AS_VALUE, NilValue: 0},
+			expected: `{"name":"John","score":2.5}` + "\n" + `{"name":"Jane","score":0}` + "\n",
+		},
+		{
+			df: empty,
+			opts: JSONOptions{Indent: "\t"},
+			expected: "[]\n",
+		},
+	}
+
+	for i, tr := range testData {
+		var output strings.Builder
+
+		if tr.isNDJSON {
+			err = tr.df.WriteNDJSON(&output, tr.opts)
+		} else {
+			err = tr.df.WriteJSON(&output, tr.opts)
+		}
+
+		if err != nil {
+			t.Fatalf("record %d: write error is: %s", i, err)
+		}
+
+		if output.String() != tr.expected {
+			t.Fatalf("record %d: expected %q; got %q", i, tr.expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package types
+
+import "sync"
+
+// Runs task for every i from 0 to n-1 on a pool of up to workers goroutines, or one after the other
+// if workers is 1 or less. Each task should only write to what belongs to its own i.
+// If any tasks fail, the error of the one with the lowest i is returned, as it would be if they ran serially
+func runTasks(workers int, n int, task func(i int) error) error {
+	if workers > n {
+		workers = n
+	}
+
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			err := task(i)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	errs := make([]error, n)
+	tasks := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range tasks {
+				errs[i] = task(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		tasks <- i
+	}
+
+	close(tasks)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Splits the positions from 0 to count into one chunk per worker, and runs task on each chunk
+// from start (included) to end (excluded) on a pool of up to workers goroutines.
+// If any chunks fail, the error of the first of them is returned
+func runInChunks(workers int, count int, task func(start int, end int) error) error {
+	if workers <= 1 || count <= 1 {
+		return task(0, count)
+	}
+
+	chunkSize := (count + workers - 1) / workers
+	noOfChunks := (count + chunkSize - 1) / chunkSize
+
+	return runTasks(workers, noOfChunks, func(i int) error {
+		start := i * chunkSize
+		end := start + chunkSize
+		if end > count {
+			end = count
+		}
+
+		return task(start, end)
+	})
+}
@@ -0,0 +1,165 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync/atomic"
+	"testing"
+)
+
+// runTasks should run every task once, and return the error of the failing task with the lowest position
+func TestRunTasks(t *testing.T)  {
+	for _, workers := range []int{0, 1, 3, 100} {
+		var calls int64
+		err := runTasks(workers, 10, func(i int) error {
+			atomic.AddInt64(&calls, 1)
+			if i == 4 || i == 7 {
+				return fmt.Errorf("task %d failed", i)
+			}
+
+			return nil
+		})
+
+		if err == nil || err.Error() != "task 4 failed" {
+			t.Fatalf("workers %d: expected the error of task 4, got %v", workers, err)
+		}
+
+		if workers > 1 && calls != 10 {
+			t.Fatalf("workers %d: expected 10 calls, got %d", workers, calls)
+		}
+	}
+
+	for _, workers := range []int{1, 3, 4, 20} {
+		covered := make([]int, 10)
+		runInChunks(workers, len(covered), func(start int, end int) error {
+			for i := start; i < end; i++ {
+				covered[i]++
+			}
+
+			return nil
+		})
+
+		for i, times := range covered {
+			if times != 1 {
+				t.Fatalf("workers %d: position %d covered %d times", workers, i, times)
+			}
+		}
+	}
+}
+
+// Operations on a dataframe with a parallelism should give exactly the same results as on one without
+func TestDataframe_WithParallelism(t *testing.T)  {
+	records := make([]map[string]interface{}, 500)
+	for i := range records {
+		records[i] = map[string]interface{}{"id": i, "name": fmt.Sprintf("name-%d", i % 37), "age": i % 90}
+		if i % 11 == 0 {
+			records[i]["age"] = nil
+		}
+
+		if i % 13 == 0 {
+			records[i]["score"] = float64(i) / 4
+		}
+	}
+
+	mutate := func(df *Dataframe) error {
+		err := df.Insert([]map[string]interface{}{{"id": 600, "name": "late", "age": 2.5, "tags": "x"}})
+		if err != nil {
+			return err
+		}
+
+		err = df.Update(df.Col("age").GreaterThan(70), map[string]interface{}{"name": "old", "rank": 1})
+		if err != nil {
+			return err
+		}
+
+		return df.Delete(df.Col("name").IsLike(regexp.MustCompile("-1")))
+	}
+
+	serial, err := FromArray(records, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	parallel, err := FromArray(records, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	parallel.WithParallelism(4)
+
+	for _, df := range []*Dataframe{serial, parallel} {
+		err = mutate(df)
+		if err != nil {
+			t.Fatalf("mutation error is: %s", err)
+		}
+	}
+
+	if !reflect.DeepEqual(serial.Keys(), parallel.Keys()) {
+		t.Fatalf("keys expected: %v, got %v", serial.Keys(), parallel.Keys())
+	}
+
+	for _, name := range serial.ColumnNames() {
+		if serial.Col(name).Dtype() != parallel.Col(name).Dtype() || !reflect.DeepEqual(serial.Col(name).Items(), parallel.Col(name).Items()) {
+			t.Fatalf("column %s expected: %v, got %v", name, serial.Col(name).Items(), parallel.Col(name).Items())
+		}
+	}
+
+	type testRecord struct {
+		name string;
+		run func(df *Dataframe) (interface{}, error);
+	}
+
+	testData := []testRecord{
+		{name: "predicates", run: func(df *Dataframe) (interface{}, error) {
+			return []filterType{
+				df.Col("age").GreaterThan(df.Col("score")),
+				df.Col("age").LessOrEquals(30),
+				df.Col("name").Equals("old"),
+				df.Col("age").IsIn(1, 2.5, nil),
+				df.Col("score").Between(10, 40),
+				df.Col("score").IsNull(),
+				df.Col("name").StartsWith("name-2"),
+			}, nil
+		}},
+		{name: "expression", run: func(df *Dataframe) (interface{}, error) {
+			return df.Select("id", "age").Where(Col("age").Mul(2).Gt(Col("score")).Or(Col("name").Eq("late"))).Execute()
+		}},
+		{name: "expression error", run: func(df *Dataframe) (interface{}, error) {
+			return df.Select().Where(Col("name").Add(1).Gt(0)).Execute()
+		}},
+		{name: "apply", run: func(df *Dataframe) (interface{}, error) {
+			return df.Select("id", "age", "name").Apply(
+				df.Col("age").Tx(func(v interface{}) interface{} {
+					if v, ok := v.(int); ok && v % 2 == 0 {
+						return v / 2
+					}
+
+					return v
+				}),
+				df.Col("name").Tx(func(v interface{}) interface{} { return fmt.Sprintf("%v!", v) }),
+			).SortBy(df.Col("age").Order(DESC)).Execute()
+		}},
+		{name: "to array", run: func(df *Dataframe) (interface{}, error) {
+			copied, err := df.Copy()
+			if err != nil {
+				return nil, err
+			}
+
+			return copied.ToArray()
+		}},
+	}
+
+	for _, tr := range testData {
+		expected, expectedErr := tr.run(serial)
+		got, err := tr.run(parallel)
+
+		if fmt.Sprint(expectedErr) != fmt.Sprint(err) {
+			t.Fatalf("%s: error expected: %v, got %v", tr.name, expectedErr, err)
+		}
+
+		if !reflect.DeepEqual(expected, got) {
+			t.Fatalf("%s: expected %v, got %v", tr.name, expected, got)
+		}
+	}
+}
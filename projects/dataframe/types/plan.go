@@ -0,0 +1,481 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// The logical plan of a query: the actions of the query combined and reordered into the steps that execute them.
+// Only the columns the later steps need are read from the dataframe, and the filters are fused into one
+// and applied as the rows are read, so that rows and columns that are not needed are never copied
+type queryPlan struct {
+	df *Dataframe
+	// the columns read from the dataframe, in order
+	columns []string
+	// the filters of the query, fused with AND when the plan is executed
+	conditions []condition
+	gopt *groupByOption
+	sortOptions []sortOption
+	windows []windowExpr
+	txs map[string][]rowWiseFunc
+	// the maximum number of rows returned; -1 for no limit
+	limit int
+	// the number of rows skipped before the limit rows
+	offset int
+	// the fields whose distinct values are kept; nil if duplicates are kept
+	distinctFields []string
+	// the fields returned; all fields if empty
+	selectedFields []string
+}
+
+// Returns the logical plan of the query, the steps from reading the dataframe to selecting the fields,
+// one per line from the last step to the first, with the estimated number of rows after each step
+func (q *query) Explain() (string, error) {
+	plan, err := q.plan()
+	if err != nil {
+		return "", err
+	}
+
+	return plan.describe()
+}
+
+// Builds the logical plan of the query, pruning the columns, aggregations and transformations
+// whose results are not selected
+func (q *query) plan() (*queryPlan, error) {
+	// may need to add a recover defer
+	p := queryPlan{df: q.df, limit: -1}
+	var gopt *groupByOption
+	txList := []transformation{}
+
+	// combine similar actions together
+	for _, act := range q.ops {
+		switch act._type {
+		case FILTER_ACTION:
+			p.conditions = append(p.conditions, act.payload.(condition))
+		case GROUPBY_ACTION:
+			gopt = act.payload.(*groupByOption)
+		case WINDOW_ACTION:
+			p.windows = append(p.windows, act.payload.([]windowExpr)...)
+		case SORT_ACTION:
+			p.sortOptions = append(p.sortOptions, act.payload.([]sortOption)...)
+		case APPLY_ACTION:
+			txList = append(txList, act.payload.([]transformation)...)
+		case SELECT_ACTION:
+			p.selectedFields = append(p.selectedFields, act.payload.([]string)...)
+		case LIMIT_ACTION:
+			p.limit = act.payload.(int)
+			if p.limit < 0 {
+				return nil, fmt.Errorf("limit error: the number of rows %d is negative", p.limit)
+			}
+		case OFFSET_ACTION:
+			p.offset = act.payload.(int)
+			if p.offset < 0 {
+				return nil, fmt.Errorf("offset error: the number of rows %d is negative", p.offset)
+			}
+		case DISTINCT_ACTION:
+			p.distinctFields = append([]string{}, act.payload.([]string)...)
+		}
+	}
+
+	if gopt != nil {
+		p.selectedFields = gopt.expandSelection(p.selectedFields)
+	}
+
+	// the window columns are selected along with the selected fields
+	for _, expr := range p.windows {
+		if len(p.selectedFields) > 0 && !utils.ContainsString(p.selectedFields, expr.name) {
+			p.selectedFields = append(p.selectedFields, expr.name)
+		}
+	}
+
+	isAllSelected := len(p.selectedFields) == 0
+	// the fields that the steps after grouping refer to
+	usedFields := append([]string{}, p.selectedFields...)
+	for _, option := range p.sortOptions {
+		for field := range option {
+			usedFields = append(usedFields, field)
+		}
+	}
+
+	for _, expr := range p.windows {
+		usedFields = append(usedFields, expr.spec.getFields()...)
+	}
+
+	usedFields = append(usedFields, p.distinctFields...)
+
+	if gopt != nil {
+		// aggregations whose results are not used are dropped, except those clashing with the grouped fields
+		// so that the clash is still reported
+		p.gopt = &groupByOption{fields: gopt.fields, q: gopt.q, resample: gopt.resample}
+		for _, agg := range gopt.aggs {
+			if isAllSelected || utils.ContainsString(usedFields, agg.name) || utils.ContainsString(gopt.fields, agg.name) {
+				p.gopt.aggs = append(p.gopt.aggs, agg)
+			}
+		}
+	}
+
+	p.txs = mergeTransformations(txList)
+	if !isAllSelected {
+		for field := range p.txs {
+			if !utils.ContainsString(p.selectedFields, field) {
+				delete(p.txs, field)
+			}
+		}
+	}
+
+	// the primary fields are always read, as the rows are keyed by them
+	readFields := append([]string{}, q.df.pkFields...)
+	if isAllSelected {
+		readFields = append(readFields, q.df.ColumnNames()...)
+	} else if p.gopt != nil {
+		readFields = append(readFields, p.gopt.fields...)
+		for _, agg := range p.gopt.aggs {
+			readFields = append(readFields, agg.field)
+		}
+	} else {
+		readFields = append(readFields, usedFields...)
+	}
+
+	for _, field := range readFields {
+		if _, ok := q.df.cols[field]; ok && !utils.ContainsString(p.columns, field) {
+			p.columns = append(p.columns, field)
+		}
+	}
+
+	return &p, nil
+}
+
+// Executes the plan on the dataframe, returning the resulting dataframe and the fields that were selected
+func (p *queryPlan) execute() (*Dataframe, []string, error) {
+	filter, err := p.getFilter()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// if no step needs all the rows, the rows outside the offset and limit are not even read
+	offset, limit := 0, -1
+	if p.isStreamed() {
+		offset, limit = p.offset, p.limit
+	}
+
+	df := p.df.scan(p.columns, filter, offset, limit)
+
+	if p.gopt != nil && p.gopt.resample != nil {
+		err = df.resample(p.gopt.fields[0], *p.gopt.resample)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if p.gopt != nil {
+		df, err = df.getGroupedDf(p.gopt)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if p.gopt.resample != nil {
+			df, err = df.getSortedDf(sortOption{p.gopt.fields[0]: ASC})
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if p.isTopK() {
+		df, err = df.getTopKDf(p.offset + p.limit, p.sortOptions...)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if len(p.sortOptions) > 0 {
+		df, err = df.getSortedDf(p.sortOptions...)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(p.windows) > 0 {
+		err = df.applyWindows(p.windows)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(p.txs) > 0 {
+		err = df.apply(p.txs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if p.distinctFields != nil {
+		err = df.dropDuplicates(p.getDistinctFields(df), KEEP_FIRST)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if p.isTopK() && p.offset > 0 {
+		df.keepRows(p.offset, -1)
+	} else if !p.isTopK() && !p.isStreamed() && (p.offset > 0 || p.limit >= 0) {
+		df.keepRows(p.offset, p.limit)
+	}
+
+	return df, p.selectedFields, nil
+}
+
+// Describes the steps of the plan, from the last to the first, each indented below the step that consumes its rows
+func (p *queryPlan) describe() (string, error) {
+	filter, err := p.getFilter()
+	if err != nil {
+		return "", err
+	}
+
+	steps := []string{}
+	rows := p.estimateFilteredRows(filter)
+
+	scan := fmt.Sprintf("SCAN [%s]", strings.Join(p.columns, ", "))
+	if len(p.conditions) > 0 {
+		descriptions := make([]string, len(p.conditions))
+		for i, c := range p.conditions {
+			descriptions[i] = describeCondition(c)
+		}
+
+		scan += fmt.Sprintf(" FILTER %s", strings.Join(descriptions, " AND "))
+	}
+
+	if p.isStreamed() {
+		scan += " " + describeSlice(p.offset, p.limit)
+		rows = getSlicedRows(rows, p.offset, p.limit)
+	}
+	steps = append(steps, fmt.Sprintf("%s (rows: %d of %d)", scan, rows, p.df.Count()))
+
+	if p.gopt != nil {
+		aggs := make([]string, 0, len(p.gopt.aggs))
+		for name, agg := range mergeAggregations(p.gopt.aggs) {
+			aggs = append(aggs, fmt.Sprintf("%s AS %s", agg.field, name))
+		}
+		sort.Strings(aggs)
+
+		step := fmt.Sprintf("GROUPBY [%s]", strings.Join(p.gopt.fields, ", "))
+		if p.gopt.resample != nil {
+			step = fmt.Sprintf("RESAMPLE %s %v", p.gopt.fields[0], *p.gopt.resample)
+		}
+
+		rows = p.estimateGroups(rows)
+		steps = append(steps, fmt.Sprintf("%s AGG [%s] (rows: ~%d)", step, strings.Join(aggs, ", "), rows))
+	}
+
+	if len(p.sortOptions) > 0 {
+		orders := []string{}
+		for _, option := range p.sortOptions {
+			for field, order := range option {
+				direction := "ASC"
+				if order == DESC {
+					direction = "DESC"
+				}
+
+				orders = append(orders, fmt.Sprintf("%s %s", field, direction))
+			}
+		}
+
+		step := fmt.Sprintf("SORT [%s]", strings.Join(orders, ", "))
+		if p.isTopK() {
+			step += fmt.Sprintf(" TOP %d", p.offset + p.limit)
+			rows = getSlicedRows(rows, 0, p.offset + p.limit)
+		}
+
+		steps = append(steps, fmt.Sprintf("%s (rows: ~%d)", step, rows))
+	}
+
+	if len(p.windows) > 0 {
+		names := make([]string, len(p.windows))
+		for i, expr := range p.windows {
+			names[i] = expr.name
+		}
+
+		steps = append(steps, fmt.Sprintf("WINDOW [%s] (rows: ~%d)", strings.Join(names, ", "), rows))
+	}
+
+	if len(p.txs) > 0 {
+		fields := make([]string, 0, len(p.txs))
+		for field := range p.txs {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		steps = append(steps, fmt.Sprintf("APPLY [%s] (rows: ~%d)", strings.Join(fields, ", "), rows))
+	}
+
+	if p.distinctFields != nil {
+		fields := p.distinctFields
+		if len(fields) == 0 {
+			fields = p.selectedFields
+		}
+
+		distinct := "*"
+		if len(fields) > 0 {
+			distinct = fmt.Sprintf("[%s]", strings.Join(fields, ", "))
+		}
+
+		steps = append(steps, fmt.Sprintf("DISTINCT %s (rows: ~%d)", distinct, rows))
+	}
+
+	if p.isTopK() && p.offset > 0 {
+		rows = getSlicedRows(rows, p.offset, -1)
+		steps = append(steps, fmt.Sprintf("%s (rows: ~%d)", describeSlice(p.offset, -1), rows))
+	} else if !p.isTopK() && !p.isStreamed() && (p.offset > 0 || p.limit >= 0) {
+		rows = getSlicedRows(rows, p.offset, p.limit)
+		steps = append(steps, fmt.Sprintf("%s (rows: ~%d)", describeSlice(p.offset, p.limit), rows))
+	}
+
+	project := "*"
+	if len(p.selectedFields) > 0 {
+		project = fmt.Sprintf("[%s]", strings.Join(p.selectedFields, ", "))
+	}
+	steps = append(steps, fmt.Sprintf("PROJECT %s (rows: ~%d)", project, rows))
+
+	lines := make([]string, len(steps))
+	for i := range steps {
+		lines[i] = strings.Repeat("  ", i) + steps[len(steps)-1-i]
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// Checks whether the offset and limit can be applied while the rows are read,
+// as none of the steps after reading them needs all the rows
+func (p *queryPlan) isStreamed() bool {
+	return (p.offset > 0 || p.limit >= 0) && p.gopt == nil && len(p.sortOptions) == 0 && len(p.windows) == 0 &&
+		p.distinctFields == nil
+}
+
+// Checks whether only the first offset + limit rows need to be sorted, as none of the steps after sorting
+// needs all the rows
+func (p *queryPlan) isTopK() bool {
+	return p.limit >= 0 && len(p.sortOptions) > 0 && len(p.windows) == 0 && p.distinctFields == nil
+}
+
+// Returns the fields whose distinct values are kept: those given to Distinct,
+// else the selected fields found in the dataframe, else all fields
+func (p *queryPlan) getDistinctFields(df *Dataframe) []string {
+	if len(p.distinctFields) > 0 {
+		return p.distinctFields
+	}
+
+	fields := []string{}
+	for _, field := range p.selectedFields {
+		if _, ok := df.cols[field]; ok {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// Evaluates the filters of the plan against the dataframe, and fuses them into one. It returns nil if there are none
+func (p *queryPlan) getFilter() (filterType, error) {
+	filters := make([]filterType, len(p.conditions))
+	for i, c := range p.conditions {
+		filter, err := c.getFilter(p.df)
+		if err != nil {
+			return nil, err
+		}
+
+		filters[i] = filter
+	}
+
+	return fuseFilters(filters), nil
+}
+
+// Returns the number of rows that pass the fused filter
+func (p *queryPlan) estimateFilteredRows(filter filterType) int {
+	count := p.df.Count()
+	if filter == nil {
+		return count
+	}
+
+	rows := 0
+	for position := 0; position < count; position++ {
+		if position >= len(filter) || filter[position] {
+			rows++
+		}
+	}
+
+	return rows
+}
+
+// Estimates the number of groups of the given number of rows, as the product of the number of distinct values
+// of the grouped fields in the whole dataframe, but no more than the rows
+func (p *queryPlan) estimateGroups(rows int) int {
+	groups := 1
+	for _, field := range p.gopt.fields {
+		if groups >= rows {
+			break
+		}
+
+		col, ok := p.df.cols[field]
+		if !ok {
+			continue
+		}
+
+		distinct := map[interface{}]struct{}{}
+		for _, value := range col.Items() {
+			distinct[getDistinctKey(value)] = struct{}{}
+		}
+
+		groups *= len(distinct)
+	}
+
+	if groups > rows {
+		return rows
+	}
+
+	return groups
+}
+
+// Combines the filters into a single filter with AND, without changing any of them. It returns nil if there are none
+func fuseFilters(filters []filterType) filterType {
+	if len(filters) == 0 {
+		return nil
+	}
+
+	// AND may change the filters in place, so it is given copies
+	copies := make([]filterType, len(filters))
+	for i, filter := range filters {
+		copies[i] = append(filterType{}, filter...)
+	}
+
+	return AND(copies...)
+}
+
+// Returns the number of rows left of the given number of rows after skipping offset rows
+// and keeping at most limit rows; a negative limit keeps all of them
+func getSlicedRows(rows int, offset int, limit int) int {
+	rows -= offset
+	if rows < 0 {
+		rows = 0
+	}
+
+	if limit >= 0 && limit < rows {
+		rows = limit
+	}
+
+	return rows
+}
+
+// Describes an offset and a limit for Explain, e.g. "LIMIT 10 OFFSET 20"
+func describeSlice(offset int, limit int) string {
+	parts := []string{}
+	if limit >= 0 {
+		parts = append(parts, fmt.Sprintf("LIMIT %d", limit))
+	}
+
+	if offset > 0 {
+		parts = append(parts, fmt.Sprintf("OFFSET %d", offset))
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,167 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// Explain should describe the steps of the plan with the pruned columns, the fused filters and the estimated rows
+func TestQuery_Explain(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	q := df.Select("location", "age").
+		Where(df.Col("age").GreaterThan(20)).
+		Where(df.Col("last name").Equals("Roe")).
+		GroupBy("location").Agg(df.Col("age").Agg(SUM), df.Col("first name").Agg(COUNT).As("unused")).
+		SortBy(df.Col("age").Order(DESC)).
+		Limit(1)
+
+	expected := strings.Join([]string{
+		"PROJECT [location, age] (rows: ~1)",
+		"  SORT [age DESC] TOP 1 (rows: ~1)",
+		"    GROUPBY [location] AGG [age AS age] (rows: ~3)",
+		"      SCAN [first name, last name, location, age] FILTER <mask> AND <mask> (rows: 3 of 6)",
+	}, "\n")
+
+	plan, err := q.Explain()
+	if err != nil {
+		t.Fatalf("explain error is: %s", err)
+	}
+
+	if plan != expected {
+		t.Fatalf("plan expected:\n%s\ngot:\n%s", expected, plan)
+	}
+
+	records, err := q.Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 1 || records[0]["location"] != "Nairobi" || records[0]["age"] != 79.0 || len(records[0]) != 2 {
+		t.Fatalf("expected [map[age:79 location:Nairobi]], got %v", records)
+	}
+}
+
+// The plan should read only the columns that are needed, and drop transformations of columns that are not selected
+func TestQuery_plan(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	q := df.Select("first name").
+		SortBy(df.Col("age").Order(ASC)).
+		Apply(df.Col("location").Tx(func(v interface{}) interface{} { return strings.ToUpper(v.(string)) }))
+
+	plan, err := q.plan()
+	if err != nil {
+		t.Fatalf("plan error is: %s", err)
+	}
+
+	expectedColumns := []string{"first name", "last name", "age"}
+	if !utils.AreStringSliceEqual(plan.columns, expectedColumns) {
+		t.Fatalf("columns expected: %v, got %v", expectedColumns, plan.columns)
+	}
+
+	if len(plan.txs) != 0 {
+		t.Fatalf("expected no transformations, got %v", plan.txs)
+	}
+
+	records, err := q.Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	expectedNames := []string{"Paul", "John", "Richard", "Reyna", "Jane", "Ruth"}
+	for i, record := range records {
+		if len(record) != 1 || record["first name"] != expectedNames[i] {
+			t.Fatalf("the record %d expected first name %s, got %v", i, expectedNames[i], records)
+		}
+	}
+
+	plan, err = df.Select().plan()
+	if err != nil {
+		t.Fatalf("plan error is: %s", err)
+	}
+
+	if !utils.AreStringSliceEqual(utils.SortStringSlice(plan.columns, utils.ASC), expectedCols) {
+		t.Fatalf("columns expected: %v, got %v", expectedCols, plan.columns)
+	}
+}
+
+// Executing a query should not change its filters, so it gives the same results every time
+func TestQuery_ExecuteTwice(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	filter := df.Col("location").Equals("Kampala")
+	q := df.Select("first name").Where(filter).Where(df.Col("age").GreaterThan(20))
+
+	for loop := 0; loop < 2; loop++ {
+		records, err := q.Execute()
+		if err != nil {
+			t.Fatalf("loop %d, execute error is: %s", loop, err)
+		}
+
+		if len(records) != 2 || records[0]["first name"] != "John" || records[1]["first name"] != "Ruth" {
+			t.Fatalf("loop %d, expected John and Ruth, got %v", loop, records)
+		}
+	}
+
+	expectedFilter := filterType{true, false, true, false, false, true}
+	for i, value := range expectedFilter {
+		if filter[i] != value {
+			t.Fatalf("filter expected: %v, got %v", expectedFilter, filter)
+		}
+	}
+}
+
+// Explain should show the offset and limit applied while the rows are read if no step needs all the rows
+func TestQuery_ExplainStreamed(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	q := df.Select("first name").Where(Col("age").Gt(20)).Offset(1).Limit(2)
+	expected := strings.Join([]string{
+		"PROJECT [first name] (rows: ~2)",
+		`  SCAN [first name, last name] FILTER (age > 20) LIMIT 2 OFFSET 1 (rows: 2 of 6)`,
+	}, "\n")
+
+	plan, err := q.Explain()
+	if err != nil {
+		t.Fatalf("explain error is: %s", err)
+	}
+
+	if plan != expected {
+		t.Fatalf("plan expected:\n%s\ngot:\n%s", expected, plan)
+	}
+
+	q = df.Select("first name").SortBy(df.Col("age").Order(ASC)).Window(
+		df.Col("age").Over(OrderBy(df.Col("age").Order(ASC))).Apply(ROW_NUMBER).As("n"),
+	).Offset(4)
+	expected = strings.Join([]string{
+		"PROJECT [first name, n] (rows: ~2)",
+		"  OFFSET 4 (rows: ~2)",
+		"    WINDOW [n] (rows: ~6)",
+		"      SORT [age ASC] (rows: ~6)",
+		"        SCAN [first name, last name, age] (rows: 6 of 6)",
+	}, "\n")
+
+	plan, err = q.Explain()
+	if err != nil {
+		t.Fatalf("explain error is: %s", err)
+	}
+
+	if plan != expected {
+		t.Fatalf("plan expected:\n%s\ngot:\n%s", expected, plan)
+	}
+}
@@ -1,22 +1,25 @@
 package types
 
+import "github.com/learn-along/learn-go/projects/dataframe/utils"
+
 const (
 	ASC sortOrder = iota
 	DESC
 )
 
 const (
-	// This order is important. 
-	// filter first, 
-	// then group, 
-	// then sort each group,
-	// then apply whatever,
-	// then select the field
+	// These only tag the actions; their order does not matter.
+	// The plan runs the steps in its own order, whatever order they were added in:
+	// filter, group, sort, window, apply, select, distinct, then offset and limit
 	FILTER_ACTION actionType = iota
 	GROUPBY_ACTION
 	SORT_ACTION
+	WINDOW_ACTION
 	APPLY_ACTION
 	SELECT_ACTION
+	LIMIT_ACTION
+	OFFSET_ACTION
+	DISTINCT_ACTION
 )
 
 type action struct {
@@ -39,6 +42,8 @@ type groupByOption struct {
 	fields []string
 	aggs []aggregation
 	q *query
+	// the period of the buckets if the rows are grouped by Resample
+	resample *timePeriod
 }
 
 // aggregates the different groups
@@ -48,6 +53,23 @@ func (g *groupByOption) Agg(aggs...aggregation) *query {
 	return g.q
 }
 
+// Adds the names of the aggregations of the selected fields to the selected fields,
+// so that renamed aggregations are selected along with the columns they aggregate
+func (g *groupByOption) expandSelection(selectedFields []string) []string {
+	if len(selectedFields) == 0 {
+		return selectedFields
+	}
+
+	expanded := append([]string{}, selectedFields...)
+	for _, agg := range g.aggs {
+		if utils.ContainsString(selectedFields, agg.field) && !utils.ContainsString(expanded, agg.name) {
+			expanded = append(expanded, agg.name)
+		}
+	}
+
+	return expanded
+}
+
 /**
 * query
 */
@@ -58,65 +80,43 @@ type query struct{
 
 // Actually executes the query
 func (q *query) Execute() ([]map[string]interface{}, error) {
-	// may need to add a recover defer
-	var gopt *groupByOption
-	filters := []filterType{}
-	sortOptions := []sortOption{}
-	txList := []transformation{}
-	selectedFields := []string{}
-
-	// combine similar actions together
-	for _, act := range q.ops {
-		switch act._type {
-		case FILTER_ACTION:
-			filters = append(filters, act.payload.(filterType))
-		case GROUPBY_ACTION:
-			gopt = act.payload.(*groupByOption)
-		case SORT_ACTION:
-			sortOptions = append(sortOptions, act.payload.([]sortOption)...)
-		case APPLY_ACTION:
-			txList = append(txList, act.payload.([]transformation)...)
-		case SELECT_ACTION:
-			selectedFields = append(selectedFields, act.payload.([]string)...)
-		}
-	}
-
-	df, err := q.df.getFilteredDf(AND(filters...))
+	df, selectedFields, err := q.run()
 	if err != nil {
 		return nil, err
 	}
 
-	if gopt != nil {
-		df, err = df.getGroupedDf(gopt)
-		if err != nil {
-			return nil, err
-		}
-	}
+	return df.ToArray(selectedFields...)
+}
 
-	if len(sortOptions) > 0 {
-		df, err = df.getSortedDf(sortOptions...)
-		if err != nil {
-			return nil, err
-		}
+// Executes the query and returns the result as a new Dataframe, that holds only the selected columns.
+// If the query has a GroupBy, the grouped fields are the primary key fields of the new Dataframe,
+// else the primary key fields stay the same, and are used for the index even if they are not selected
+func (q *query) Collect() (*Dataframe, error) {
+	df, selectedFields, err := q.run()
+	if err != nil {
+		return nil, err
 	}
 
-	if len(txList) > 0 {
-		mergedTxs := mergeTransformations(txList)	
-		err = df.apply(mergedTxs)
-		if err != nil {
-			return nil, err
-		}		
+	df.keepCols(selectedFields...)
+	return df, nil
+}
+
+// Runs the actions of the query, following its plan, on a copy of the dataframe,
+// returning the resulting copy and the fields that were selected
+func (q *query) run() (*Dataframe, []string, error) {
+	plan, err := q.plan()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// FIXME: Could we return a dataframe instead of converting this to an array first
-	// This could mean that less columns are even generated and passed out
-	return df.ToArray(selectedFields...)	
+	return plan.execute()
 }
 
 // Given a list of boolean corresponding to indices of the items,
 // true meaning the item should be included, false meaning that item should be excluded
-// the method then returns a query instance
-func (q *query) Where(filter filterType) *query {
+// the method then returns a query instance.
+// The filter can also be an expression e.g. Col("age").Gt(30), which is evaluated when the query is executed
+func (q *query) Where(filter condition) *query {
 	q.ops = append(q.ops, action{_type: FILTER_ACTION, payload: filter})
 	return q
 }
@@ -132,6 +132,26 @@ func (q *query) GroupBy(fields ...string) *groupByOption {
 	return &groupByOption{q: q, fields: fields, aggs: []aggregation{}}
 }
 
+// Computes the window functions for each row, adding their results as new columns
+func (q *query) Window(exprs ...windowExpr) *query {
+	q.ops = append(q.ops, action{_type: WINDOW_ACTION, payload: exprs})
+	return q
+}
+
+// Keeps only the first n rows of the result, after any rows skipped by Offset.
+// If the query is sorted, only the rows needed are kept in order, instead of sorting all rows.
+// A negative n makes the query return an error
+func (q *query) Limit(n int) *query {
+	q.ops = append(q.ops, action{_type: LIMIT_ACTION, payload: n})
+	return q
+}
+
+// Skips the first n rows of the result, e.g. to page through it with Limit. A negative n makes the query return an error
+func (q *query) Offset(n int) *query {
+	q.ops = append(q.ops, action{_type: OFFSET_ACTION, payload: n})
+	return q
+}
+
 // Applies the col transforms to the query
 func (q *query) Apply(ops ...transformation) *query {
 	q.ops = append(q.ops, action{_type: APPLY_ACTION, payload: ops})
@@ -2,6 +2,8 @@ package types
 
 import (
 	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
 )
 
 // AND combines a list of lists of booleans into a list of booleans
@@ -63,3 +65,204 @@ func TestNOT(t *testing.T)  {
 		}
 	}	
 }
+
+// Collect should return the result of the query as a new Dataframe holding only the selected columns,
+// whose primary fields are the grouped fields if the query groups the data
+func TestQuery_Collect(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	type testRecord struct {
+		q *query;
+		expectedCols []string;
+		expectedPkFields []string;
+		expectedKeys []string;
+	}
+
+	testData := []testRecord{
+		{
+			q: df.Select("age", "first name").Where(df.Col("age").GreaterThan(40)),
+			expectedCols: []string{"age", "first name"},
+			expectedPkFields: primaryFields,
+			expectedKeys: []string{"Jane_Doe", "Reyna_Roe", "Ruth_Roe"},
+		},
+		{
+			q: df.Select("age", "location").GroupBy("location").Agg(df.Col("age").Agg(MAX)),
+			expectedCols: []string{"age", "location"},
+			expectedPkFields: []string{"location"},
+			expectedKeys: []string{"Kampala", "Lusaka", "Nairobi"},
+		},
+		{
+			q: df.Select(),
+			expectedCols: expectedCols,
+			expectedPkFields: primaryFields,
+			expectedKeys: keys,
+		},
+	}
+
+	for i, tr := range testData {
+		result, err := tr.q.Collect()
+		if err != nil {
+			t.Fatalf("record %d: collect error is: %s", i, err)
+		}
+
+		colNames := utils.SortStringSlice(result.ColumnNames(), utils.ASC)
+		if !utils.AreStringSliceEqual(colNames, tr.expectedCols){
+			t.Fatalf("record %d: cols expected: %v, got: %v", i, tr.expectedCols, colNames)
+		}
+
+		if !utils.AreStringSliceEqual(result.pkFields, tr.expectedPkFields){
+			t.Fatalf("record %d: pkFields expected: %v, got %v", i, tr.expectedPkFields, result.pkFields)
+		}
+
+		if !utils.AreStringSliceEqual(result.Keys(), tr.expectedKeys) {
+			t.Fatalf("record %d: keys expected: %v, got: %v", i, tr.expectedKeys, result.Keys())
+		}
+	}
+
+	// the result can be queried further, without affecting the original dataframe
+	result, err := df.Select("age", "location").GroupBy("location").Agg(df.Col("age").Agg(MAX)).Collect()
+	if err != nil {
+		t.Fatalf("collect error is: %s", err)
+	}
+
+	records, err := result.Select().Where(result.Col("age").GreaterThan(55)).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 1 || records[0]["location"] != "Kampala" || records[0]["age"] != 60.0 {
+		t.Fatalf("expected [map[age:60 location:Kampala]]; got %v", records)
+	}
+
+	if df.Count() != len(dataArray) || len(df.ColumnNames()) != noOfExpectedCols {
+		t.Fatalf("the original dataframe should not change, got %d records and cols %v", df.Count(), df.ColumnNames())
+	}
+}
+
+// Agg should reject aggregations that are renamed to any of the grouped fields
+func TestGroupByOption_AggNameClash(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	_, err = df.Select().GroupBy("location").Agg(df.Col("age").Agg(MAX).As("location")).Execute()
+	if err == nil {
+		t.Fatalf("expected an error for an aggregation named after a grouped field")
+	}
+}
+
+// Limit and Offset should page through the result, the same way whether or not the rows are sorted with a top-k heap
+func TestQuery_LimitOffset(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	age := df.Col("age")
+
+	type testRecord struct {
+		q *query;
+		expected []string;
+	}
+
+	testData := []testRecord{
+		{q: df.Select("first name").Limit(2), expected: []string{"John", "Jane"}},
+		{q: df.Select("first name").Offset(4), expected: []string{"Reyna", "Ruth"}},
+		{q: df.Select("first name").Offset(1).Limit(2), expected: []string{"Jane", "Paul"}},
+		{q: df.Select("first name").Limit(0), expected: []string{}},
+		{q: df.Select("first name").Offset(10), expected: []string{}},
+		{q: df.Select("first name").Where(Col("location").Eq("Kampala")).Offset(1).Limit(5), expected: []string{"Paul", "Ruth"}},
+		{q: df.Select("first name").SortBy(age.Order(DESC)).Offset(1).Limit(2), expected: []string{"Jane", "Reyna"}},
+		// equal rows keep their order
+		{q: df.Select("first name").SortBy(df.Col("last name").Order(ASC)).Limit(2), expected: []string{"John", "Jane"}},
+		{q: df.Select("first name").SortBy(df.Col("last name").Order(DESC)).Offset(2).Limit(2), expected: []string{"Ruth", "John"}},
+		{q: df.Select("location").GroupBy("location").Agg().SortBy(df.Col("location").Order(DESC)).Limit(1), expected: []string{"Nairobi"}},
+	}
+
+	for loop, tr := range testData {
+		records, err := tr.q.Execute()
+		if err != nil {
+			t.Fatalf("loop %d, execute error is: %s", loop, err)
+		}
+
+		if len(records) != len(tr.expected) {
+			t.Fatalf("loop %d, expected %v, got %v", loop, tr.expected, records)
+		}
+
+		for i, name := range tr.expected {
+			for _, value := range records[i] {
+				if value != name {
+					t.Fatalf("loop %d, expected %v, got %v", loop, tr.expected, records)
+				}
+			}
+		}
+	}
+
+	// the window functions still see all the rows
+	records, err := df.Select("first name").SortBy(age.Order(DESC)).Window(
+		age.Over(OrderBy(age.Order(ASC))).Apply(ROW_NUMBER).As("n"),
+	).Limit(2).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 2 || records[0]["n"] != 6 || records[1]["n"] != 5 {
+		t.Fatalf("expected row numbers 6 and 5, got %v", records)
+	}
+
+	// the top-k rows should be the first rows of the fully sorted result
+	sorted, err := df.Select("first name").SortBy(df.Col("location").Order(ASC), age.Order(DESC)).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	for k := 0; k <= len(sorted); k++ {
+		top, err := df.Select("first name").SortBy(df.Col("location").Order(ASC), age.Order(DESC)).Limit(k).Execute()
+		if err != nil {
+			t.Fatalf("k %d, execute error is: %s", k, err)
+		}
+
+		if len(top) != k {
+			t.Fatalf("k %d, expected %d records, got %v", k, k, top)
+		}
+
+		for i := range top {
+			if top[i]["first name"] != sorted[i]["first name"] {
+				t.Fatalf("k %d, expected %v, got %v", k, sorted[:k], top)
+			}
+		}
+	}
+
+	// negative numbers of rows are rejected rather than ignored
+	for _, q := range []*query{df.Select("first name").Limit(-1), df.Select("first name").Offset(-2).Limit(1)} {
+		_, err = q.Collect()
+		if err == nil {
+			t.Fatalf("expected an error for a negative limit or offset")
+		}
+
+		_, err = q.Execute()
+		if err == nil {
+			t.Fatalf("expected an error for a negative limit or offset")
+		}
+	}
+
+	// the rows dropped by Offset, Limit and Distinct make no version of the collected dataframe
+	for _, q := range []*query{
+		df.Select("first name").Offset(1).Limit(2),
+		df.Select("first name").SortBy(age.Order(DESC)).Offset(1).Limit(2),
+		df.Select("last name").Distinct(),
+	} {
+		collected, err := q.Collect()
+		if err != nil {
+			t.Fatalf("collect error is: %s", err)
+		}
+
+		if history := collected.History(); len(history) != 1 || history[0].Operation != "create" {
+			t.Fatalf("expected only the created version, got %v", history)
+		}
+	}
+}
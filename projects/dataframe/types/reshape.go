@@ -0,0 +1,151 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// The names of the columns that hold the names and the values of the melted columns
+const (
+	MELT_VARIABLE = "variable"
+	MELT_VALUE = "value"
+)
+
+// Spreads the distinct values of the columns fields into new columns, one row for each distinct set of index values,
+// and returns the result as a new Dataframe whose primary fields are the index fields.
+// The values of each values field are aggregated with agg for each set of index and columns values.
+// The new columns are named after the columns values joined by "_", prefixed by the values field
+// if there is more than one values field e.g. "sales_2021". Missing combinations are nil
+func (d *Dataframe) Pivot(index []string, columns []string, values []string, agg aggregateFunc) (*Dataframe, error) {
+	if len(index) == 0 || len(columns) == 0 || len(values) == 0 {
+		return nil, fmt.Errorf("pivot error: index, columns and values must each have at least one field")
+	}
+
+	for _, fields := range [][]string{index, columns, values} {
+		for _, field := range fields {
+			if _, ok := d.cols[field]; !ok {
+				return nil, fmt.Errorf("pivot error: column '%s' not found", field)
+			}
+		}
+	}
+
+	gopt := &groupByOption{fields: append(append([]string{}, index...), columns...)}
+	for _, field := range values {
+		gopt.aggs = append(gopt.aggs, d.cols[field].Agg(agg))
+	}
+
+	grouped, err := d.getGroupedDf(gopt)
+	if err != nil {
+		return nil, err
+	}
+
+	groupedRecords, err := grouped.ToArray()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []map[string]interface{}{}
+	positions := map[string]int{}
+	columnValues := make([]string, len(columns))
+
+	for _, groupedRecord := range groupedRecords {
+		key, err := createKey(groupedRecord, index)
+		if err != nil {
+			return nil, err
+		}
+
+		position, ok := positions[key]
+		if !ok {
+			record := make(map[string]interface{}, len(index))
+			for _, field := range index {
+				record[field] = groupedRecord[field]
+			}
+
+			position = len(records)
+			positions[key] = position
+			records = append(records, record)
+		}
+
+		for i, field := range columns {
+			columnValues[i] = fmt.Sprintf("%v", groupedRecord[field])
+		}
+
+		name := strings.Join(columnValues, "_")
+		for _, field := range values {
+			newName := name
+			if len(values) > 1 {
+				newName = fmt.Sprintf("%s_%s", field, name)
+			}
+
+			if utils.ContainsString(index, newName) {
+				return nil, fmt.Errorf("pivot error: the new column '%s' is an index field", newName)
+			}
+
+			records[position][newName] = groupedRecord[field]
+		}
+	}
+
+	return FromArray(records, index)
+}
+
+// Turns the valueVars columns into rows, each holding the name of the column in a "variable" column
+// and its value in a "value" column, alongside the idVars columns.
+// If valueVars is empty, all columns other than the idVars are melted.
+// The primary fields are always kept as id columns, so the primary fields of the new Dataframe
+// are those of this dataframe and "variable". The rows are ordered by variable, then by the original order
+func (d *Dataframe) Melt(idVars []string, valueVars []string) (*Dataframe, error) {
+	ids := append([]string{}, d.pkFields...)
+	for _, field := range idVars {
+		if !utils.ContainsString(ids, field) {
+			ids = append(ids, field)
+		}
+	}
+
+	if len(valueVars) == 0 {
+		for name := range d.cols {
+			if !utils.ContainsString(ids, name) {
+				valueVars = append(valueVars, name)
+			}
+		}
+
+		sort.Strings(valueVars)
+	}
+
+	for _, field := range append(append([]string{}, ids...), valueVars...) {
+		if _, ok := d.cols[field]; !ok {
+			return nil, fmt.Errorf("melt error: column '%s' not found", field)
+		}
+	}
+
+	for _, field := range ids {
+		if field == MELT_VARIABLE || field == MELT_VALUE {
+			return nil, fmt.Errorf("melt error: the id column '%s' clashes with the melted columns", field)
+		}
+	}
+
+	count := d.Count()
+	records := make([]map[string]interface{}, 0, count*len(valueVars))
+
+	// FIXME: concurrency possible as the value columns are independent
+	for _, variable := range valueVars {
+		err := d.iterRows(append(ids, variable), func(row []interface{}) error {
+			record := make(map[string]interface{}, len(ids)+2)
+			for i, field := range ids {
+				record[field] = row[i]
+			}
+
+			record[MELT_VARIABLE] = variable
+			record[MELT_VALUE] = row[len(ids)]
+			records = append(records, record)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return FromArray(records, append(append([]string{}, d.pkFields...), MELT_VARIABLE))
+}
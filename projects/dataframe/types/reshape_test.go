@@ -0,0 +1,168 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// Pivot should spread the distinct values of the columns fields into new columns, aggregating the values fields
+// for each set of index values, with nil for missing combinations
+func TestDataframe_Pivot(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	type testRecord struct {
+		index []string;
+		columns []string;
+		values []string;
+		agg aggregateFunc;
+		expectedPkFields []string;
+		expectedCols []string;
+		expected []map[string]interface{};
+	}
+
+	testData := []testRecord{
+		{
+			index: []string{"last name"},
+			columns: []string{"location"},
+			values: []string{"age"},
+			agg: SUM,
+			expectedPkFields: []string{"last name"},
+			expectedCols: []string{"Kampala", "Lusaka", "Nairobi", "last name"},
+			expected: []map[string]interface{}{
+				{"last name": "Doe", "Kampala": 49.0, "Lusaka": 50.0, "Nairobi": nil},
+				{"last name": "Roe", "Kampala": 60.0, "Lusaka": nil, "Nairobi": 79.0},
+			},
+		},
+		{
+			index: []string{"location"},
+			columns: []string{"last name"},
+			values: []string{"age", "first name"},
+			agg: MAX,
+			expectedPkFields: []string{"location"},
+			expectedCols: []string{"age_Doe", "age_Roe", "first name_Doe", "first name_Roe", "location"},
+			expected: []map[string]interface{}{
+				{"location": "Kampala", "age_Doe": 30.0, "age_Roe": 60.0, "first name_Doe": "Paul", "first name_Roe": "Ruth"},
+				{"location": "Lusaka", "age_Doe": 50.0, "age_Roe": nil, "first name_Doe": "Jane", "first name_Roe": nil},
+				{"location": "Nairobi", "age_Doe": nil, "age_Roe": 45.0, "first name_Doe": nil, "first name_Roe": "Richard"},
+			},
+		},
+	}
+
+	for loop, tr := range testData {
+		pivoted, err := df.Pivot(tr.index, tr.columns, tr.values, tr.agg)
+		if err != nil {
+			t.Fatalf("loop %d, pivot error is: %s", loop, err)
+		}
+
+		if !utils.AreStringSliceEqual(pivoted.pkFields, tr.expectedPkFields){
+			t.Fatalf("loop %d, pkFields expected: %v, got %v", loop, tr.expectedPkFields, pivoted.pkFields)
+		}
+
+		colNames := utils.SortStringSlice(pivoted.ColumnNames(), utils.ASC)
+		if !utils.AreStringSliceEqual(colNames, tr.expectedCols){
+			t.Fatalf("loop %d, cols expected: %v, got: %v", loop, tr.expectedCols, colNames)
+		}
+
+		records, err := pivoted.ToArray()
+		if err != nil {
+			t.Fatalf("loop %d, error on ToArray is: %s", loop, err)
+		}
+
+		if len(records) != len(tr.expected) {
+			t.Fatalf("loop %d, expected number of records: %d, got %d", loop, len(tr.expected), len(records))
+		}
+
+		for i, record := range tr.expected {
+			for field, expectedValue := range record {
+				if expectedValue != records[i][field] {
+					t.Fatalf("loop %d, the record %d expected %v, got %v", loop, i, record, records[i])
+				}
+			}
+		}
+	}
+
+	_, err = df.Pivot([]string{"last name"}, []string{"country"}, []string{"age"}, SUM)
+	if err == nil {
+		t.Fatalf("expected an error for a missing columns field")
+	}
+}
+
+// Melt should turn the value columns into rows of variable and value, keeping the primary fields,
+// and Pivot should be able to turn them back
+func TestDataframe_Melt(t *testing.T)  {
+	data := []map[string]interface{}{
+		{"id": 1, "name": "John", "age": 30, "city": "Kampala"},
+		{"id": 2, "name": "Jane", "age": 50, "city": "Lusaka"},
+	}
+
+	df, err := FromArray(data, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	melted, err := df.Melt([]string{"city"}, nil)
+	if err != nil {
+		t.Fatalf("melt error is: %s", err)
+	}
+
+	expectedPkFields := []string{"id", MELT_VARIABLE}
+	if !utils.AreStringSliceEqual(melted.pkFields, expectedPkFields){
+		t.Fatalf("pkFields expected: %v, got %v", expectedPkFields, melted.pkFields)
+	}
+
+	expectedKeys := []string{"1_age", "2_age", "1_name", "2_name"}
+	if !utils.AreStringSliceEqual(melted.Keys(), expectedKeys) {
+		t.Fatalf("keys expected: %v, got: %v", expectedKeys, melted.Keys())
+	}
+
+	expected := []map[string]interface{}{
+		{"id": 1, "city": "Kampala", "variable": "age", "value": 30},
+		{"id": 2, "city": "Lusaka", "variable": "age", "value": 50},
+		{"id": 1, "city": "Kampala", "variable": "name", "value": "John"},
+		{"id": 2, "city": "Lusaka", "variable": "name", "value": "Jane"},
+	}
+
+	records, err := melted.ToArray()
+	if err != nil {
+		t.Fatalf("error on ToArray is: %s", err)
+	}
+
+	for i, record := range expected {
+		if len(records[i]) != len(record) {
+			t.Fatalf("the record %d expected %v, got %v", i, record, records[i])
+		}
+
+		for field, expectedValue := range record {
+			if expectedValue != records[i][field] {
+				t.Fatalf("the record %d expected %v, got %v", i, record, records[i])
+			}
+		}
+	}
+
+	restored, err := melted.Pivot([]string{"id", "city"}, []string{MELT_VARIABLE}, []string{MELT_VALUE}, FIRST)
+	if err != nil {
+		t.Fatalf("pivot error is: %s", err)
+	}
+
+	records, err = restored.ToArray()
+	if err != nil {
+		t.Fatalf("error on ToArray is: %s", err)
+	}
+
+	for i, record := range data {
+		for field, expectedValue := range record {
+			if expectedValue != records[i][field] {
+				t.Fatalf("the record %d expected %v, got %v", i, record, records[i])
+			}
+		}
+	}
+
+	_, err = df.Melt([]string{"country"}, nil)
+	if err == nil {
+		t.Fatalf("expected an error for a missing id column")
+	}
+}
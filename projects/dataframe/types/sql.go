@@ -0,0 +1,903 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+const (
+	sqlEOF sqlTokenType = iota
+	sqlIdent
+	sqlKeyword
+	sqlNumber
+	sqlString
+	sqlSymbol
+)
+
+// The words reserved by the SQL subset. Keywords are case-insensitive
+var sqlKeywords = map[string]struct{}{
+	"SELECT": {}, "DISTINCT": {}, "FROM": {}, "WHERE": {}, "GROUP": {}, "BY": {}, "ORDER": {}, "LIMIT": {}, "OFFSET": {}, "AS": {},
+	"AND": {}, "OR": {}, "NOT": {}, "LIKE": {}, "ASC": {}, "DESC": {}, "TRUE": {}, "FALSE": {},
+}
+
+// The operators comparing columns to literals, other than LIKE
+var sqlComparisons = map[string]struct{}{
+	"=": {}, "!=": {}, "<>": {}, "<": {}, "<=": {}, ">": {}, ">=": {},
+}
+
+// The aggregate functions that can be called in SQL queries, by their upper-case names
+var sqlAggregates = map[string]aggregateFunc{
+	"SUM": SUM,
+	"MIN": MIN,
+	"MAX": MAX,
+	"MEAN": MEAN,
+	"AVG": MEAN,
+	"COUNT": COUNT,
+	"COUNT_NON_NULL": COUNT_NON_NULL,
+	"COUNT_DISTINCT": COUNT_DISTINCT,
+	"RANGE": RANGE,
+	"MEDIAN": MEDIAN,
+	"VARIANCE": VARIANCE,
+	"VARIANCE_SAMPLE": VARIANCE_SAMPLE,
+	"STDDEV": STDDEV,
+	"STDDEV_SAMPLE": STDDEV_SAMPLE,
+	"MODE": MODE,
+	"FIRST": FIRST,
+	"LAST": LAST,
+	"COLLECT": COLLECT,
+}
+
+type sqlTokenType int
+
+// Error describing a SQL query that cannot be parsed or planned, and where in the query the problem is
+type SQLError struct {
+	// the line and column (both starting at 1) of the problem in the query
+	Line int
+	Column int
+	Message string
+}
+
+func (e *SQLError) Error() string {
+	return fmt.Sprintf("sql error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Registry of dataframes that SQL queries can be run against, by table name
+type SQLContext struct {
+	tables map[string]*Dataframe
+}
+
+// Creates a new SQLContext with no tables
+func NewSQLContext() *SQLContext {
+	return &SQLContext{tables: map[string]*Dataframe{}}
+}
+
+// Registers the dataframe under the given table name, replacing any dataframe registered under that name
+func (c *SQLContext) Register(name string, df *Dataframe) {
+	c.tables[name] = df
+}
+
+// Parses the SQL query and compiles it into a query on the registered dataframe it selects from.
+// The subset supported is:
+//
+//	SELECT [DISTINCT] * | item [, item ...] FROM table
+//	[WHERE condition] [GROUP BY column [, column ...]]
+//	[ORDER BY name [ASC | DESC] [, ...]] [LIMIT count] [OFFSET count]
+//
+// where an item is a column or an aggregate such as SUM(column) or COUNT(*), optionally followed by AS alias,
+// and a condition compares columns to literals with =, !=, <>, <, <=, >, >= or LIKE, combined with AND, OR, NOT
+// and parentheses. Comparisons with nil items are unknown, so neither they nor their negations select the rows.
+// The condition is compiled into an expression, so it is evaluated on the rows as they are when the query is executed.
+// An aggregate is named after its column, or "count" for COUNT(*), unless aliased, and items must have distinct names.
+// Names with spaces can be quoted with double quotes or backticks.
+// Errors are returned as *SQLError, giving the position of the problem
+func (c *SQLContext) Query(sql string) (*query, error) {
+	tokens, err := tokenizeSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := sqlParser{sql: sql, tokens: tokens}
+	stmt, err := parser.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.plan(stmt)
+}
+
+/*
+* Lexer
+*/
+
+type sqlToken struct {
+	_type sqlTokenType
+	// the text of the token; upper-case for keywords, unquoted for strings and quoted identifiers
+	text string
+	// the offset of the token in the query
+	pos int
+}
+
+// Splits the SQL query into tokens
+func tokenizeSQL(sql string) ([]sqlToken, error) {
+	tokens := []sqlToken{}
+	runes := []rune(sql)
+	offsets := make([]int, len(runes)+1)
+
+	offset := 0
+	for i, r := range runes {
+		offsets[i] = offset
+		offset += len(string(r))
+	}
+	offsets[len(runes)] = offset
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			word := string(runes[start:i])
+			if _, ok := sqlKeywords[strings.ToUpper(word)]; ok {
+				tokens = append(tokens, sqlToken{_type: sqlKeyword, text: strings.ToUpper(word), pos: offsets[start]})
+			} else {
+				tokens = append(tokens, sqlToken{_type: sqlIdent, text: word, pos: offsets[start]})
+			}
+		case unicode.IsDigit(r) || (r == '.' || r == '-') && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			text := string(runes[start:i])
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, newSQLError(sql, offsets[start], fmt.Sprintf("malformed number '%s'", text))
+			}
+
+			tokens = append(tokens, sqlToken{_type: sqlNumber, text: text, pos: offsets[start]})
+		case r == '\'' || r == '"' || r == '`':
+			var text strings.Builder
+			closed := false
+
+			for i++; i < len(runes); i++ {
+				if runes[i] == r {
+					// a doubled quote is an escaped quote
+					if i+1 < len(runes) && runes[i+1] == r {
+						text.WriteRune(r)
+						i++
+						continue
+					}
+
+					closed = true
+					i++
+					break
+				}
+
+				text.WriteRune(runes[i])
+			}
+
+			if !closed {
+				return nil, newSQLError(sql, offsets[start], "unterminated quoted text")
+			}
+
+			_type := sqlIdent
+			if r == '\'' {
+				_type = sqlString
+			}
+
+			tokens = append(tokens, sqlToken{_type: _type, text: text.String(), pos: offsets[start]})
+		default:
+			symbol := string(r)
+			if i+1 < len(runes) {
+				if _, ok := sqlComparisons[string(runes[i:i+2])]; ok {
+					symbol = string(runes[i : i+2])
+				}
+			}
+
+			if _, ok := sqlComparisons[symbol]; !ok && !strings.ContainsRune("(),*", r) {
+				return nil, newSQLError(sql, offsets[start], fmt.Sprintf("unexpected character %q", r))
+			}
+
+			i += len([]rune(symbol))
+			tokens = append(tokens, sqlToken{_type: sqlSymbol, text: symbol, pos: offsets[start]})
+		}
+	}
+
+	tokens = append(tokens, sqlToken{_type: sqlEOF, pos: len(sql)})
+	return tokens, nil
+}
+
+// Creates a SQLError for the given offset in the query, converting the offset into a line and a column
+func newSQLError(sql string, pos int, message string) *SQLError {
+	line, column := 1, 1
+
+	for _, r := range sql[:pos] {
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return &SQLError{Line: line, Column: column, Message: message}
+}
+
+/*
+* Parser
+*/
+
+// A parsed SELECT statement
+type sqlStatement struct {
+	// the text of the query, for the positions of errors
+	sql string
+	isDistinct bool
+	isStar bool
+	items []sqlSelectItem
+	table sqlToken
+	where sqlExpr
+	groupBy []sqlToken
+	orderBy []sqlOrderItem
+	limit *sqlToken
+	offset *sqlToken
+}
+
+// A column or an aggregate in the select list
+type sqlSelectItem struct {
+	column sqlToken
+	// the aggregate function called, if any
+	function *sqlToken
+	// true for COUNT(*)
+	isStar bool
+	alias *sqlToken
+}
+
+// A name in the ORDER BY list and its order
+type sqlOrderItem struct {
+	name sqlToken
+	order sortOrder
+}
+
+// A condition in the WHERE clause
+type sqlExpr interface{}
+
+// AND or OR of two conditions
+type sqlLogicalExpr struct {
+	op string
+	left sqlExpr
+	right sqlExpr
+}
+
+// NOT of a condition
+type sqlNotExpr struct {
+	expr sqlExpr
+}
+
+// Comparison of a column to a literal
+type sqlComparison struct {
+	column sqlToken
+	op sqlToken
+	value sqlToken
+}
+
+// Recursive descent parser of the tokens of a SQL query
+type sqlParser struct {
+	sql string
+	tokens []sqlToken
+	current int
+}
+
+// Parses the whole query as a single SELECT statement
+func (p *sqlParser) parseStatement() (*sqlStatement, error) {
+	stmt := sqlStatement{sql: p.sql}
+
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+
+	if p.isKeyword("DISTINCT") {
+		p.next()
+		stmt.isDistinct = true
+	}
+
+	if p.isSymbol("*") {
+		p.next()
+		stmt.isStar = true
+	} else {
+		for {
+			item, err := p.parseSelectItem()
+			if err != nil {
+				return nil, err
+			}
+
+			stmt.items = append(stmt.items, item)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+
+	table, err := p.expectIdent("a table name")
+	if err != nil {
+		return nil, err
+	}
+	stmt.table = table
+
+	if p.isKeyword("WHERE") {
+		p.next()
+		stmt.where, err = p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isKeyword("GROUP") {
+		p.next()
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+
+		for {
+			field, err := p.expectIdent("a column name")
+			if err != nil {
+				return nil, err
+			}
+
+			stmt.groupBy = append(stmt.groupBy, field)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("ORDER") {
+		p.next()
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+
+		for {
+			name, err := p.expectIdent("a column name")
+			if err != nil {
+				return nil, err
+			}
+
+			item := sqlOrderItem{name: name, order: ASC}
+			if p.isKeyword("ASC") {
+				p.next()
+			} else if p.isKeyword("DESC") {
+				p.next()
+				item.order = DESC
+			}
+
+			stmt.orderBy = append(stmt.orderBy, item)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("LIMIT") {
+		p.next()
+		stmt.limit, err = p.expectCount()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isKeyword("OFFSET") {
+		p.next()
+		stmt.offset, err = p.expectCount()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if token := p.peek(); token._type != sqlEOF {
+		return nil, p.errorAt(token, "expected the end of the query")
+	}
+
+	return &stmt, nil
+}
+
+// Parses a column or an aggregate, with its alias if any
+func (p *sqlParser) parseSelectItem() (sqlSelectItem, error) {
+	item := sqlSelectItem{}
+
+	name, err := p.expectIdent("a column name or an aggregate")
+	if err != nil {
+		return item, err
+	}
+
+	if p.isSymbol("(") {
+		p.next()
+		item.function = &name
+
+		if p.isSymbol("*") {
+			item.isStar = true
+			item.column = p.next()
+		} else {
+			item.column, err = p.expectIdent("a column name")
+			if err != nil {
+				return item, err
+			}
+		}
+
+		if err := p.expectSymbol(")"); err != nil {
+			return item, err
+		}
+	} else {
+		item.column = name
+	}
+
+	if p.isKeyword("AS") {
+		p.next()
+		alias, err := p.expectIdent("an alias")
+		if err != nil {
+			return item, err
+		}
+
+		item.alias = &alias
+	}
+
+	return item, nil
+}
+
+// Parses conditions joined by OR
+func (p *sqlParser) parseOr() (sqlExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = sqlLogicalExpr{op: "OR", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// Parses conditions joined by AND
+func (p *sqlParser) parseAnd() (sqlExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = sqlLogicalExpr{op: "AND", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// Parses a condition that may be negated with NOT
+func (p *sqlParser) parseNot() (sqlExpr, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return sqlNotExpr{expr: expr}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+// Parses a condition in parentheses or a comparison of a column to a literal
+func (p *sqlParser) parsePrimary() (sqlExpr, error) {
+	if p.isSymbol("(") {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+
+		return expr, nil
+	}
+
+	column, err := p.expectIdent("a column name")
+	if err != nil {
+		return nil, err
+	}
+
+	isNegated := false
+	if p.isKeyword("NOT") {
+		p.next()
+		isNegated = true
+		if !p.isKeyword("LIKE") {
+			return nil, p.errorAt(p.peek(), "expected LIKE")
+		}
+	}
+
+	op := p.peek()
+	_, isComparison := sqlComparisons[op.text]
+	if !(op._type == sqlSymbol && isComparison) && !p.isKeyword("LIKE") {
+		return nil, p.errorAt(op, "expected a comparison operator")
+	}
+	p.next()
+
+	value := p.peek()
+	if value._type != sqlNumber && value._type != sqlString && !p.isKeyword("TRUE") && !p.isKeyword("FALSE") {
+		return nil, p.errorAt(value, "expected a number, a quoted string, TRUE or FALSE")
+	}
+	p.next()
+
+	var expr sqlExpr = sqlComparison{column: column, op: op, value: value}
+	if isNegated {
+		expr = sqlNotExpr{expr: expr}
+	}
+
+	return expr, nil
+}
+
+// Returns the current token without consuming it
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.current]
+}
+
+// Consumes and returns the current token
+func (p *sqlParser) next() sqlToken {
+	token := p.tokens[p.current]
+	if token._type != sqlEOF {
+		p.current++
+	}
+
+	return token
+}
+
+// Checks whether the current token is the given keyword
+func (p *sqlParser) isKeyword(keyword string) bool {
+	token := p.peek()
+	return token._type == sqlKeyword && token.text == keyword
+}
+
+// Checks whether the current token is the given symbol
+func (p *sqlParser) isSymbol(symbol string) bool {
+	token := p.peek()
+	return token._type == sqlSymbol && token.text == symbol
+}
+
+// Consumes the current token if it is the given keyword, else returns an error
+func (p *sqlParser) expectKeyword(keyword string) error {
+	if !p.isKeyword(keyword) {
+		return p.errorAt(p.peek(), fmt.Sprintf("expected %s", keyword))
+	}
+
+	p.next()
+	return nil
+}
+
+// Consumes and returns the current token if it is a whole number that is not negative, else returns an error
+func (p *sqlParser) expectCount() (*sqlToken, error) {
+	token := p.peek()
+	if count, err := strconv.Atoi(token.text); token._type != sqlNumber || err != nil || count < 0 {
+		return nil, p.errorAt(token, "expected a whole number")
+	}
+
+	p.next()
+	return &token, nil
+}
+
+// Consumes the current token if it is the given symbol, else returns an error
+func (p *sqlParser) expectSymbol(symbol string) error {
+	if !p.isSymbol(symbol) {
+		return p.errorAt(p.peek(), fmt.Sprintf("expected '%s'", symbol))
+	}
+
+	p.next()
+	return nil
+}
+
+// Consumes and returns the current token if it is an identifier, else returns an error describing what was expected
+func (p *sqlParser) expectIdent(description string) (sqlToken, error) {
+	token := p.peek()
+	if token._type != sqlIdent {
+		return token, p.errorAt(token, fmt.Sprintf("expected %s", description))
+	}
+
+	return p.next(), nil
+}
+
+// Returns a syntax error at the position of the token
+func (p *sqlParser) errorAt(token sqlToken, message string) *SQLError {
+	found := fmt.Sprintf("'%s'", token.text)
+	if token._type == sqlEOF {
+		found = "the end of the query"
+	}
+
+	return newSQLError(p.sql, token.pos, fmt.Sprintf("syntax error: %s, found %s", message, found))
+}
+
+/*
+* Planner
+*/
+
+// Compiles the parsed statement into a query on the registered dataframe
+func (c *SQLContext) plan(stmt *sqlStatement) (*query, error) {
+	df, ok := c.tables[stmt.table.text]
+	if !ok {
+		return nil, c.errorAt(stmt, stmt.table, fmt.Sprintf("unknown table '%s'", stmt.table.text))
+	}
+
+	fields := []string{}
+	aggs := []aggregation{}
+	groupFields := make([]string, len(stmt.groupBy))
+
+	for i, token := range stmt.groupBy {
+		if _, ok := df.cols[token.text]; !ok {
+			return nil, c.errorAt(stmt, token, fmt.Sprintf("unknown column '%s'", token.text))
+		}
+		groupFields[i] = token.text
+	}
+
+	for _, item := range stmt.items {
+		if item.function == nil {
+			if _, ok := df.cols[item.column.text]; !ok {
+				return nil, c.errorAt(stmt, item.column, fmt.Sprintf("unknown column '%s'", item.column.text))
+			}
+
+			if item.alias != nil {
+				return nil, c.errorAt(stmt, *item.alias, "aliases are only supported for aggregates")
+			}
+
+			fields = append(fields, item.column.text)
+			continue
+		}
+
+		aggFunc, ok := sqlAggregates[strings.ToUpper(item.function.text)]
+		if !ok {
+			return nil, c.errorAt(stmt, *item.function, fmt.Sprintf("unknown aggregate function '%s'", item.function.text))
+		}
+
+		field := item.column.text
+		name := field
+		mode := NULL_SKIP
+
+		if item.isStar {
+			if strings.ToUpper(item.function.text) != "COUNT" || len(df.pkFields) == 0 {
+				return nil, c.errorAt(stmt, item.column, "'*' can only be counted, as in COUNT(*)")
+			}
+
+			// COUNT includes nils when they are kept, so any column gives the number of rows
+			field = df.pkFields[0]
+			name = "count"
+			mode = NULL_AS_ZERO
+		} else if _, ok := df.cols[field]; !ok {
+			return nil, c.errorAt(stmt, item.column, fmt.Sprintf("unknown column '%s'", field))
+		}
+
+		position := *item.function
+		if item.alias != nil {
+			name = item.alias.text
+			position = *item.alias
+		}
+
+		// the aggregates are merged by name, so one of two aggregates of the same name would be lost
+		if utils.ContainsString(fields, name) {
+			return nil, c.errorAt(stmt, position, fmt.Sprintf("more than one item is named '%s'; name them apart with AS", name))
+		}
+
+		aggs = append(aggs, df.Col(field).Agg(aggFunc).As(name).WithNulls(mode))
+		fields = append(fields, name)
+	}
+
+	isGrouped := len(groupFields) > 0 || len(aggs) > 0
+	if isGrouped {
+		if stmt.isStar {
+			return nil, c.errorAt(stmt, stmt.table, "SELECT * cannot be used with GROUP BY")
+		}
+
+		for _, item := range stmt.items {
+			if item.function == nil && !utils.ContainsString(groupFields, item.column.text) {
+				return nil, c.errorAt(stmt, item.column,
+					fmt.Sprintf("column '%s' must be in the GROUP BY clause or be aggregated", item.column.text))
+			}
+		}
+	}
+
+	q := df.Select(fields...)
+
+	if stmt.where != nil {
+		filter, err := c.compileCondition(stmt, df, stmt.where)
+		if err != nil {
+			return nil, err
+		}
+
+		q = q.Where(filter)
+	}
+
+	if isGrouped {
+		q = q.GroupBy(groupFields...).Agg(aggs...)
+	}
+
+	if len(stmt.orderBy) > 0 {
+		options := make([]sortOption, len(stmt.orderBy))
+		for i, item := range stmt.orderBy {
+			_, isColumn := df.cols[item.name.text]
+			if isGrouped && !utils.ContainsString(fields, item.name.text) || !isGrouped && !isColumn {
+				return nil, c.errorAt(stmt, item.name, fmt.Sprintf("unknown column '%s'", item.name.text))
+			}
+
+			options[i] = sortOption{item.name.text: item.order}
+		}
+
+		q = q.SortBy(options...)
+	}
+
+	if stmt.isDistinct {
+		q = q.Distinct()
+	}
+
+	if stmt.limit != nil {
+		limit, _ := strconv.Atoi(stmt.limit.text)
+		q = q.Limit(limit)
+	}
+
+	if stmt.offset != nil {
+		offset, _ := strconv.Atoi(stmt.offset.text)
+		q = q.Offset(offset)
+	}
+
+	return q, nil
+}
+
+// Compiles the condition into an expression, evaluated against the rows when the query is executed,
+// so that it sees the rows as they are then. Only the columns and literals are checked beforehand.
+// Expressions follow SQL's three-valued logic: a comparison with a nil item is unknown rather than false,
+// so negating it with NOT, != or <> does not select the row either
+func (c *SQLContext) compileCondition(stmt *sqlStatement, df *Dataframe, expr sqlExpr) (expression, error) {
+	switch expr := expr.(type) {
+	case sqlLogicalExpr:
+		left, err := c.compileCondition(stmt, df, expr.left)
+		if err != nil {
+			return expression{}, err
+		}
+
+		right, err := c.compileCondition(stmt, df, expr.right)
+		if err != nil {
+			return expression{}, err
+		}
+
+		if expr.op == "AND" {
+			return left.And(right), nil
+		}
+
+		return left.Or(right), nil
+	case sqlNotExpr:
+		condition, err := c.compileCondition(stmt, df, expr.expr)
+		return condition.Not(), err
+	case sqlComparison:
+		col, ok := df.cols[expr.column.text]
+		if !ok {
+			return expression{}, c.errorAt(stmt, expr.column, fmt.Sprintf("unknown column '%s'", expr.column.text))
+		}
+
+		return c.compileComparison(stmt, col, expr)
+	}
+
+	return expression{}, fmt.Errorf("sql error: unsupported condition %v", expr)
+}
+
+// Compiles the comparison of the column to a literal into an expression
+func (c *SQLContext) compileComparison(stmt *sqlStatement, col *Column, expr sqlComparison) (expression, error) {
+	value, err := getSQLLiteral(expr.value, col.Dtype())
+	if err != nil {
+		return expression{}, c.errorAt(stmt, expr.value, err.Error())
+	}
+
+	column := Col(col.Name)
+
+	switch expr.op.text {
+	case "=":
+		return column.Eq(Lit(value)), nil
+	case "!=", "<>":
+		return column.Neq(Lit(value)), nil
+	case "LIKE":
+		pattern, ok := value.(string)
+		if !ok {
+			return expression{}, c.errorAt(stmt, expr.value, "LIKE expects a quoted string")
+		}
+
+		return column.Like(likeToRegexp(pattern)), nil
+	}
+
+	if _, ok := toFloat64(value); !ok {
+		return expression{}, c.errorAt(stmt, expr.value, fmt.Sprintf("%s expects a number", expr.op.text))
+	}
+
+	switch expr.op.text {
+	case "<":
+		return column.Lt(Lit(value)), nil
+	case "<=":
+		return column.Lte(Lit(value)), nil
+	case ">":
+		return column.Gt(Lit(value)), nil
+	default:
+		return column.Gte(Lit(value)), nil
+	}
+}
+
+// Returns an error at the position of the token in the query of the statement
+func (c *SQLContext) errorAt(stmt *sqlStatement, token sqlToken, message string) *SQLError {
+	return newSQLError(stmt.sql, token.pos, message)
+}
+
+// Converts the literal token into a value comparable to the items of a column of the given Datatype
+func getSQLLiteral(token sqlToken, dtype Datatype) (interface{}, error) {
+	switch token._type {
+	case sqlNumber:
+		if dtype != FloatType {
+			if value, err := strconv.Atoi(token.text); err == nil {
+				return value, nil
+			}
+		}
+
+		value, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed number '%s'", token.text)
+		}
+
+		return value, nil
+	case sqlKeyword:
+		return token.text == "TRUE", nil
+	default:
+		return token.text, nil
+	}
+}
+
+// Converts a LIKE pattern, in which % matches any text and _ any single character, into a regular expression
+func likeToRegexp(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
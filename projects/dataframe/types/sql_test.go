@@ -0,0 +1,263 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// Query should compile SQL queries into queries on the registered dataframes
+func TestSQLContext_Query(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	ctx := NewSQLContext()
+	ctx.Register("people", df)
+
+	type testRecord struct {
+		sql string;
+		expected []map[string]interface{};
+	}
+
+	testData := []testRecord{
+		{
+			sql: "SELECT location, SUM(age) AS total FROM people WHERE age > 20 GROUP BY location ORDER BY total DESC LIMIT 2",
+			expected: []map[string]interface{}{
+				{"location": "Kampala", "total": 90.0},
+				{"location": "Nairobi", "total": 79.0},
+			},
+		},
+		{
+			sql: `SELECT "first name", age FROM people WHERE "last name" = 'Doe' AND NOT age < 20 ORDER BY age DESC`,
+			expected: []map[string]interface{}{
+				{"first name": "Jane", "age": 50},
+				{"first name": "John", "age": 30},
+			},
+		},
+		{
+			sql: "SELECT `first name` FROM people WHERE (`first name` LIKE 'R%') OR location <> 'Kampala' ORDER BY `first name`",
+			expected: []map[string]interface{}{
+				{"first name": "Jane"},
+				{"first name": "Reyna"},
+				{"first name": "Richard"},
+				{"first name": "Ruth"},
+			},
+		},
+		{
+			sql: "select count(*) as n, avg(age) from people",
+			expected: []map[string]interface{}{
+				{"n": 6, "age": 238.0 / 6},
+			},
+		},
+		{
+			sql: "SELECT \"first name\" FROM people ORDER BY age LIMIT 2 OFFSET 3",
+			expected: []map[string]interface{}{
+				{"first name": "Reyna"},
+				{"first name": "Jane"},
+			},
+		},
+		{
+			sql: "SELECT DISTINCT location FROM people ORDER BY location DESC",
+			expected: []map[string]interface{}{
+				{"location": "Nairobi"},
+				{"location": "Lusaka"},
+				{"location": "Kampala"},
+			},
+		},
+		{
+			sql: "SELECT * FROM people WHERE age >= 45\nORDER BY age",
+			expected: []map[string]interface{}{
+				{"first name": "Reyna", "last name": "Roe", "age": 45, "location": "Nairobi"},
+				{"first name": "Jane", "last name": "Doe", "age": 50, "location": "Lusaka"},
+				{"first name": "Ruth", "last name": "Roe", "age": 60, "location": "Kampala"},
+			},
+		},
+	}
+
+	for loop, tr := range testData {
+		q, err := ctx.Query(tr.sql)
+		if err != nil {
+			t.Fatalf("loop %d, query error is: %s", loop, err)
+		}
+
+		records, err := q.Execute()
+		if err != nil {
+			t.Fatalf("loop %d, execute error is: %s", loop, err)
+		}
+
+		if len(records) != len(tr.expected) {
+			t.Fatalf("loop %d, expected %d records, got %v", loop, len(tr.expected), records)
+		}
+
+		for i, record := range tr.expected {
+			if len(records[i]) != len(record) {
+				t.Fatalf("loop %d, the record %d expected %v, got %v", loop, i, record, records[i])
+			}
+
+			for field, expectedValue := range record {
+				if expectedValue != records[i][field] {
+					t.Fatalf("loop %d, the record %d expected %v, got %v", loop, i, record, records[i])
+				}
+			}
+		}
+	}
+}
+
+// Query should return SQLErrors giving the line and column of syntax and planning errors
+func TestSQLContext_QueryErrors(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	ctx := NewSQLContext()
+	ctx.Register("people", df)
+
+	type testRecord struct {
+		sql string;
+		line int;
+		column int;
+	}
+
+	testData := []testRecord{
+		{sql: "SELECT age\nFROM people WHERE age >", line: 2, column: 24},
+		{sql: "SELECT age FROM people LIMIT x", line: 1, column: 30},
+		{sql: "SELECT age FROM people LIMIT 1 OFFSET -1", line: 1, column: 39},
+		{sql: "SELECT age FROM people WHERE location = 'x", line: 1, column: 41},
+		{sql: "SELECT age FROM people ORDER BY age DESC extra", line: 1, column: 42},
+		{sql: "SELECT age FROM people WHERE age ! 3", line: 1, column: 34},
+		{sql: "SELECT age FROM people WHERE age > 1.2.3", line: 1, column: 36},
+		{sql: "SELECT age FROM cars", line: 1, column: 17},
+		{sql: "SELECT height FROM people", line: 1, column: 8},
+		{sql: "SELECT location, age FROM people GROUP BY location", line: 1, column: 18},
+		{sql: "SELECT age FROM people WHERE location > 'K'", line: 1, column: 41},
+		{sql: "SELECT TOTAL(age) FROM people", line: 1, column: 8},
+		{sql: "SELECT age AS years FROM people", line: 1, column: 15},
+		{sql: "SELECT location, SUM(age), MAX(age) FROM people GROUP BY location", line: 1, column: 28},
+		{sql: "SELECT location, SUM(age) AS x, MAX(age) AS x FROM people GROUP BY location", line: 1, column: 45},
+		{sql: "SELECT COUNT(*), COUNT(*) FROM people", line: 1, column: 18},
+	}
+
+	for loop, tr := range testData {
+		_, err := ctx.Query(tr.sql)
+		if err == nil {
+			t.Fatalf("loop %d, expected an error for %q", loop, tr.sql)
+		}
+
+		sqlErr, ok := err.(*SQLError)
+		if !ok {
+			t.Fatalf("loop %d, expected a *SQLError, got %T: %s", loop, err, err)
+		}
+
+		if sqlErr.Line != tr.line || sqlErr.Column != tr.column {
+			t.Fatalf("loop %d, expected the error at %d:%d, got %s", loop, tr.line, tr.column, err)
+		}
+	}
+}
+
+// Conditions should follow three-valued logic, so that rows with nil items are selected by neither
+// a comparison nor its negation
+func TestSQLContext_QueryNulls(t *testing.T)  {
+	df, err := FromArray([]map[string]interface{}{
+		{"id": 1, "x": 1, "y": "a"},
+		{"id": 2, "x": 2, "y": "b"},
+		{"id": 3, "x": nil, "y": "a"},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	ctx := NewSQLContext()
+	ctx.Register("t", df)
+
+	type testRecord struct {
+		sql string;
+		expected []interface{};
+	}
+
+	testData := []testRecord{
+		{sql: "SELECT id FROM t WHERE x != 2 ORDER BY id", expected: []interface{}{1}},
+		{sql: "SELECT id FROM t WHERE x <> 2 ORDER BY id", expected: []interface{}{1}},
+		{sql: "SELECT id FROM t WHERE NOT x = 1 ORDER BY id", expected: []interface{}{2}},
+		{sql: "SELECT id FROM t WHERE NOT x < 2 ORDER BY id", expected: []interface{}{2}},
+		{sql: "SELECT id FROM t WHERE NOT (x = 1 AND y = 'a') ORDER BY id", expected: []interface{}{2}},
+		{sql: "SELECT id FROM t WHERE NOT (x = 1 OR y = 'b') ORDER BY id", expected: []interface{}{}},
+		{sql: "SELECT id FROM t WHERE x = 2 OR y = 'a' ORDER BY id", expected: []interface{}{1, 2, 3}},
+	}
+
+	for loop, tr := range testData {
+		q, err := ctx.Query(tr.sql)
+		if err != nil {
+			t.Fatalf("loop %d, query error is: %s", loop, err)
+		}
+
+		records, err := q.Execute()
+		if err != nil {
+			t.Fatalf("loop %d, execute error is: %s", loop, err)
+		}
+
+		ids := []interface{}{}
+		for _, record := range records {
+			ids = append(ids, record["id"])
+		}
+
+		if !utils.AreSliceEqual(ids, tr.expected) {
+			t.Fatalf("loop %d, ids expected: %v, got %v", loop, tr.expected, ids)
+		}
+	}
+}
+
+// The WHERE clause should be evaluated when the query is executed, so it sees the rows inserted
+// and deleted after the query was made
+func TestSQLContext_QueryEvaluatedOnExecute(t *testing.T)  {
+	df, err := FromArray([]map[string]interface{}{
+		{"id": 1, "age": 150},
+		{"id": 2, "age": 20},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	ctx := NewSQLContext()
+	ctx.Register("t", df)
+
+	q, err := ctx.Query("SELECT id FROM t WHERE age > 100 OR id = 4 ORDER BY id")
+	if err != nil {
+		t.Fatalf("query error is: %s", err)
+	}
+
+	err = df.Insert([]map[string]interface{}{{"id": 3, "age": 5}, {"id": 4, "age": 6}})
+	if err != nil {
+		t.Fatalf("insert error is: %s", err)
+	}
+
+	err = df.Delete(df.Col("id").Equals(2))
+	if err != nil {
+		t.Fatalf("delete error is: %s", err)
+	}
+
+	records, err := q.Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 2 || records[0]["id"] != 1 || records[1]["id"] != 4 {
+		t.Fatalf("expected ids 1 and 4, got %v", records)
+	}
+
+	err = df.Delete(df.Col("id").Equals(1))
+	if err != nil {
+		t.Fatalf("delete error is: %s", err)
+	}
+
+	records, err = q.Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 1 || records[0]["id"] != 4 {
+		t.Fatalf("expected id 4, got %v", records)
+	}
+}
@@ -0,0 +1,410 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// The struct tag key used to map struct fields to columns e.g. `df:"name,pk,omitempty"`
+const structTagKey = "df"
+
+// Error describing a value that could not be converted into a struct field
+type ConversionError struct {
+	Row int
+	Field string
+	Value interface{}
+	Type reflect.Type
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("row %d, field '%s': cannot convert %v (%T) to %v", e.Row, e.Field, e.Value, e.Value, e.Type)
+}
+
+// List of all the conversion errors met when filling a slice of structs
+type ConversionErrors []*ConversionError
+
+func (e ConversionErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d conversion error(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// Description of a struct field that is mapped to a column
+type structField struct {
+	name string
+	index []int
+	isPk bool
+	omitEmpty bool
+	dtype Datatype
+}
+
+// Constructs a Dataframe from a slice of structs (or pointers to structs) and returns a pointer to it.
+// Columns are named by the `df` struct tags, falling back to the field names.
+// If primaryFields is empty, the fields tagged with 'pk' are used as the primary fields
+func FromStructs(slice interface{}, primaryFields []string) (*Dataframe, error) {
+	value := reflect.ValueOf(slice)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a slice of structs, got %T", slice)
+	}
+
+	elemType := value.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a slice of structs, got %T", slice)
+	}
+
+	fields := getStructFields(elemType)
+	if len(primaryFields) == 0 {
+		for _, field := range fields {
+			if field.isPk {
+				primaryFields = append(primaryFields, field.name)
+			}
+		}
+	}
+
+	df := Dataframe{
+		pkFields: primaryFields,
+		cols: make(map[string]*Column, len(fields)),
+		index: map[interface{}]int{},
+	}
+
+	for _, field := range fields {
+		df.cols[field.name] = newColumn(field.name, field.dtype)
+	}
+
+	count := value.Len()
+	for i := 0; i < count; i++ {
+		item := reflect.Indirect(value.Index(i))
+		if !item.IsValid() {
+			return nil, fmt.Errorf("nil struct pointer at position %d", i)
+		}
+
+		record := make(map[string]interface{}, len(fields))
+
+		for _, field := range fields {
+			fieldValue, ok := getFieldValue(item, field.index)
+			if !ok || fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+				record[field.name] = nil
+				continue
+			}
+
+			fieldValue = reflect.Indirect(fieldValue)
+			if field.omitEmpty && fieldValue.IsZero() {
+				continue
+			}
+
+			record[field.name] = fieldValue.Interface()
+		}
+
+		err := df.insertRecord(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	df.normalizeCols(nil)
+	return &df, nil
+}
+
+// Fills dst, a pointer to a slice of structs (or of pointers to structs), with the records of this dataframe.
+// Values that cannot be converted to their fields are left as zero values and reported in a ConversionErrors error
+func (d *Dataframe) ToStructs(dst interface{}) error {
+	records, err := d.ToArray()
+	if err != nil {
+		return err
+	}
+
+	return recordsToStructs(records, dst)
+}
+
+// Executes the query and fills dst, a pointer to a slice of structs (or of pointers to structs), with the result.
+// Values that cannot be converted to their fields are left as zero values and reported in a ConversionErrors error
+func (q *query) ExecuteInto(dst interface{}) error {
+	records, err := q.Execute()
+	if err != nil {
+		return err
+	}
+
+	return recordsToStructs(records, dst)
+}
+
+/*
+* Helpers
+*/
+
+// Fills the slice pointed to by dst with structs built from the records
+func recordsToStructs(records []map[string]interface{}, dst interface{}) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected a pointer to a slice of structs, got %T", dst)
+	}
+
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+	isPtrElem := elemType.Kind() == reflect.Ptr
+	structType := elemType
+
+	if isPtrElem {
+		structType = elemType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a slice of structs, got %T", dst)
+	}
+
+	fields := getStructFields(structType)
+	result := reflect.MakeSlice(slice.Type(), len(records), len(records))
+	errs := ConversionErrors{}
+
+	for row, record := range records {
+		item := reflect.New(structType).Elem()
+
+		for _, field := range fields {
+			value, ok := record[field.name]
+			if !ok || value == nil {
+				continue
+			}
+
+			target := getSettableField(item, field.index)
+			if !setFieldValue(target, value) {
+				errs = append(errs, &ConversionError{Row: row, Field: field.name, Value: value, Type: target.Type()})
+			}
+		}
+
+		if isPtrElem {
+			result.Index(row).Set(item.Addr())
+		} else {
+			result.Index(row).Set(item)
+		}
+	}
+
+	slice.Set(result)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Gets the mapped fields of the struct type, flattening the embedded structs that have no name in their tags
+func getStructFields(structType reflect.Type) []structField {
+	fields := []structField{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get(structTagKey)
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}) {
+			for _, embedded := range getStructFields(fieldType) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		dtype := getDatatypeOfKind(fieldType.Kind())
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			dtype = DateTimeType
+		}
+
+		sf := structField{name: name, index: []int{i}, dtype: dtype}
+		for _, option := range parts[1:] {
+			switch option {
+			case "pk":
+				sf.isPk = true
+			case "omitempty":
+				sf.omitEmpty = true
+			}
+		}
+
+		fields = append(fields, sf)
+	}
+
+	return fields
+}
+
+// Gets the value of the possibly nested field, returning false if an embedded pointer on the way is nil
+func getFieldValue(item reflect.Value, index []int) (reflect.Value, bool) {
+	value := item
+
+	for i, position := range index {
+		if i > 0 {
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					return reflect.Value{}, false
+				}
+				value = value.Elem()
+			}
+		}
+
+		value = value.Field(position)
+	}
+
+	return value, true
+}
+
+// Gets the possibly nested field, allocating any nil embedded pointers on the way
+func getSettableField(item reflect.Value, index []int) reflect.Value {
+	value := item
+
+	for i, position := range index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+
+		value = value.Field(position)
+	}
+
+	return value
+}
+
+// Sets the value on the target field, converting it if possible. It returns false if the conversion is not possible
+func setFieldValue(target reflect.Value, value interface{}) bool {
+	if target.Kind() == reflect.Ptr {
+		elem := reflect.New(target.Type().Elem())
+		if !setFieldValue(elem.Elem(), value) {
+			return false
+		}
+
+		target.Set(elem)
+		return true
+	}
+
+	source := reflect.ValueOf(value)
+	targetType := target.Type()
+
+	if source.Type().AssignableTo(targetType) {
+		target.Set(source)
+		return true
+	}
+
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch source.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if target.OverflowInt(source.Int()) {
+				return false
+			}
+			target.SetInt(source.Int())
+			return true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if source.Uint() > math.MaxInt64 || target.OverflowInt(int64(source.Uint())) {
+				return false
+			}
+			target.SetInt(int64(source.Uint()))
+			return true
+		case reflect.Float32, reflect.Float64:
+			f := source.Float()
+			if f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 || target.OverflowInt(int64(f)) {
+				return false
+			}
+			target.SetInt(int64(f))
+			return true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch source.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if source.Int() < 0 || target.OverflowUint(uint64(source.Int())) {
+				return false
+			}
+			target.SetUint(uint64(source.Int()))
+			return true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if target.OverflowUint(source.Uint()) {
+				return false
+			}
+			target.SetUint(source.Uint())
+			return true
+		case reflect.Float32, reflect.Float64:
+			f := source.Float()
+			if f != math.Trunc(f) || f < 0 || f > math.MaxUint64 || target.OverflowUint(uint64(f)) {
+				return false
+			}
+			target.SetUint(uint64(f))
+			return true
+		}
+	case reflect.Float32, reflect.Float64:
+		switch source.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			target.SetFloat(float64(source.Int()))
+			return true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			target.SetFloat(float64(source.Uint()))
+			return true
+		case reflect.Float32, reflect.Float64:
+			if target.OverflowFloat(source.Float()) {
+				return false
+			}
+			target.SetFloat(source.Float())
+			return true
+		}
+	case reflect.String, reflect.Bool:
+		if source.Kind() == target.Kind() {
+			target.Set(source.Convert(targetType))
+			return true
+		}
+	default:
+		if source.Type().ConvertibleTo(targetType) && source.Kind() == target.Kind() {
+			target.Set(source.Convert(targetType))
+			return true
+		}
+	}
+
+	return false
+}
+
+// Maps a reflect.Kind to the Datatype of the column that would hold its values
+func getDatatypeOfKind(kind reflect.Kind) Datatype {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return IntType
+	case reflect.Float32, reflect.Float64:
+		return FloatType
+	case reflect.String:
+		return StringType
+	case reflect.Bool:
+		return BooleanType
+	case reflect.Slice, reflect.Array:
+		return ArrayType
+	default:
+		return ObjectType
+	}
+}
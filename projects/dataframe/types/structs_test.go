@@ -0,0 +1,205 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+type testPerson struct {
+	FirstName string `df:"first name,pk"`
+	LastName string `df:"last name,pk"`
+	Age int `df:"age"`
+	Location string `df:"location"`
+}
+
+type testBase struct {
+	ID int `df:"id,pk"`
+}
+
+type testAccount struct {
+	testBase
+	Owner *string `df:"owner"`
+	Balance float64 `df:"balance,omitempty"`
+	Tags []string
+	secret string
+	Ignored string `df:"-"`
+}
+
+// FromStructs should create a dataframe from a slice of structs, using the struct tags for names and primary fields
+func TestFromStructs(t *testing.T)  {
+	people := make([]testPerson, len(dataArray))
+	for i, record := range dataArray {
+		people[i] = testPerson{
+			FirstName: record["first name"].(string),
+			LastName: record["last name"].(string),
+			Age: record["age"].(int),
+			Location: record["location"].(string),
+		}
+	}
+
+	df, err := FromStructs(people, nil)
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	if !utils.AreStringSliceEqual(df.pkFields, primaryFields){
+		t.Fatalf("pkFields expected: %v, got %v", primaryFields, df.pkFields)
+	}
+
+	if !utils.AreStringSliceEqual(keys, df.Keys()) {
+		t.Fatalf("keys expected: %v, got: %v", keys, df.Keys())
+	}
+
+	for _, col := range df.cols {
+		expectedItems := utils.ExtractFieldFromMapList(dataArray, col.Name)
+		if !utils.AreSliceEqual(col.Items(), expectedItems){
+			t.Fatalf("col '%s' items expected: %v, got %v", col.Name, expectedItems, col.Items())
+		}
+	}
+
+	if df.Col("age").Dtype() != IntType {
+		t.Fatalf("dtype of 'age' expected: %v, got %v", IntType, df.Col("age").Dtype())
+	}
+}
+
+// FromStructs should flatten embedded structs, dereference pointers and leave out empty omitempty fields
+func TestFromStructsEmbeddedAndPointers(t *testing.T)  {
+	owner := "John"
+	accounts := []*testAccount{
+		{testBase: testBase{ID: 1}, Owner: &owner, Balance: 30.5, Tags: []string{"a"}, secret: "x", Ignored: "y"},
+		{testBase: testBase{ID: 2}},
+	}
+
+	df, err := FromStructs(accounts, nil)
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	expectedCols := []string{"Tags", "balance", "id", "owner"}
+	colNames := utils.SortStringSlice(df.ColumnNames(), utils.ASC)
+	if !utils.AreStringSliceEqual(colNames, expectedCols){
+		t.Fatalf("cols expected: %v, got: %v", expectedCols, colNames)
+	}
+
+	expected := map[string][]interface{}{
+		"id": {1, 2},
+		"owner": {"John", nil},
+		"balance": {30.5, nil},
+	}
+
+	for field, expectedItems := range expected {
+		if !utils.AreSliceEqual(df.Col(field).Items(), expectedItems){
+			t.Fatalf("col '%s' items expected: %v, got %v", field, expectedItems, df.Col(field).Items())
+		}
+	}
+
+	_, err = FromStructs([]int{1, 2}, nil)
+	if err == nil {
+		t.Fatalf("expected an error for a slice that is not of structs")
+	}
+}
+
+// ToStructs should fill a slice of structs with the records, converting values where possible
+// and reporting the values that cannot be converted
+func TestDataframe_ToStructs(t *testing.T)  {
+	df, err := FromArray([]map[string]interface{}{
+		{"id": 1.0, "owner": "John", "balance": 30, "Tags": []string{"a"}},
+		{"id": 2, "owner": nil, "balance": "lots", "Tags": nil},
+		{"id": 3.5, "owner": "Jane", "balance": 2.5, "Tags": nil},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	accounts := []*testAccount{}
+	err = df.ToStructs(&accounts)
+
+	errs, ok := err.(ConversionErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected 2 conversion errors, got %v", err)
+	}
+
+	if errs[0].Row != 1 || errs[0].Field != "balance" || errs[1].Row != 2 || errs[1].Field != "id" {
+		t.Fatalf("unexpected conversion errors %v", errs)
+	}
+
+	if len(accounts) != 3 {
+		t.Fatalf("expected 3 accounts, got %d", len(accounts))
+	}
+
+	first := accounts[0]
+	if first.ID != 1 || first.Owner == nil || *first.Owner != "John" || first.Balance != 30 || len(first.Tags) != 1 {
+		t.Fatalf("unexpected first account %+v", first)
+	}
+
+	second := accounts[1]
+	if second.ID != 2 || second.Owner != nil || second.Balance != 0 {
+		t.Fatalf("unexpected second account %+v", second)
+	}
+}
+
+// ExecuteInto should run the query and fill the slice of structs with the result
+func TestQuery_ExecuteInto(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	people := []testPerson{}
+	err = df.Select("first name", "age").Where(df.Col("age").GreaterThan(40)).ExecuteInto(&people)
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	expected := []testPerson{{FirstName: "Jane", Age: 50}, {FirstName: "Reyna", Age: 45}, {FirstName: "Ruth", Age: 60}}
+	if len(people) != len(expected) {
+		t.Fatalf("expected %v; got %v", expected, people)
+	}
+
+	for i, person := range expected {
+		if people[i] != person {
+			t.Fatalf("expected %v; got %v", person, people[i])
+		}
+	}
+
+	err = df.Select().ExecuteInto(people)
+	if err == nil {
+		t.Fatalf("expected an error for a destination that is not a pointer to a slice")
+	}
+}
+
+// FromStructs should hold time.Time fields in DateTimeType columns, and ToStructs should fill them back
+func TestFromStructsWithTimes(t *testing.T)  {
+	type testEvent struct {
+		ID int `df:"id,pk"`
+		At time.Time `df:"at"`
+	}
+
+	events := []testEvent{
+		{ID: 1, At: time.Date(2021, time.March, 4, 10, 0, 0, 0, time.UTC)},
+		{ID: 2, At: time.Date(2020, time.December, 31, 23, 0, 0, 0, time.UTC)},
+	}
+
+	df, err := FromStructs(events, nil)
+	if err != nil {
+		t.Fatalf("error is: %s", err)
+	}
+
+	if df.Col("at").Dtype() != DateTimeType {
+		t.Fatalf("dtype expected: %v, got %v", DateTimeType, df.Col("at").Dtype())
+	}
+
+	got := []testEvent{}
+	err = df.ToStructs(&got)
+	if err != nil {
+		t.Fatalf("ToStructs error is: %s", err)
+	}
+
+	for i, event := range events {
+		if got[i] != event {
+			t.Fatalf("the record %d expected %v, got %v", i, event, got[i])
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package types
+
+import "sync"
+
+// A Dataframe that can be shared by many goroutines.
+// Reads run on snapshots, which are read-only versions of the dataframe that never change once they are published.
+// Writes run one at a time on a new dataframe sharing the columns of the latest snapshot, which then replaces it,
+// so many readers can run while a single writer proceeds, and a failed write changes nothing.
+// A write copies only the index and the columns it changes
+type SyncDataframe struct {
+	// guards current
+	mu sync.RWMutex;
+	// allows only one writer at a time
+	writeMu sync.Mutex;
+	current *Dataframe;
+	// the subscribers to the changes of the writes, told only once a write is published
+	observers *observers;
+}
+
+// Constructs a SyncDataframe whose first snapshot is a read-only view of df, sharing its columns.
+// The df can still be mutated, copying the shared columns first, without changing the SyncDataframe.
+// The subscribers of df become subscribers of the SyncDataframe
+func NewSyncDataframe(df *Dataframe) *SyncDataframe {
+	o := df.observers
+	if o == nil {
+		o = &observers{}
+	}
+
+	return &SyncDataframe{current: df.getReadOnlyView(), observers: o}
+}
+
+// Returns the latest snapshot of the dataframe, which is read-only, so its mutations return errors.
+// Its Copy can be mutated without affecting the SyncDataframe
+func (s *SyncDataframe) Snapshot() *Dataframe {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.current
+}
+
+// Selects a given number of fields of the latest snapshot, and returns a query instance of the same.
+// The query keeps reading that snapshot even when later writes replace it
+func (s *SyncDataframe) Select(fields ...string) *query {
+	return s.Snapshot().Select(fields...)
+}
+
+// Returns the number of rows in the latest snapshot
+func (s *SyncDataframe) Count() int {
+	return s.Snapshot().Count()
+}
+
+// Inserts items passed as a list of maps into the Dataframe,
+// It will overwrite any record whose primary field values match with the new records
+func (s *SyncDataframe) Insert(records []map[string]interface{}) error {
+	return s.Write(func(df *Dataframe) error {
+		return df.Insert(records)
+	})
+}
+
+// Updates the items that fulfill the filter with the new value.
+// The filter is evaluated on the dataframe being written, so expressions like Col("age").Gt(30)
+// see the rows as they are at the time of the write
+func (s *SyncDataframe) Update(filter condition, value map[string]interface{}) error {
+	return s.Write(func(df *Dataframe) error {
+		rows, err := filter.getFilter(df)
+		if err != nil {
+			return err
+		}
+
+		return df.Update(rows, value)
+	})
+}
+
+// Deletes the items that fulfill the filter, evaluated on the dataframe being written
+func (s *SyncDataframe) Delete(filter condition) error {
+	return s.Write(func(df *Dataframe) error {
+		rows, err := filter.getFilter(df)
+		if err != nil {
+			return err
+		}
+
+		return df.Delete(rows)
+	})
+}
+
+// Merges the dataframes dfs to the Dataframe
+func (s *SyncDataframe) Merge(dfs ...*Dataframe) error {
+	return s.Write(func(df *Dataframe) error {
+		return df.Merge(dfs...)
+	})
+}
+
+// Clears all the rows and columns of the Dataframe
+func (s *SyncDataframe) Clear() error {
+	return s.Write(func(df *Dataframe) error {
+		return df.Clear()
+	})
+}
+
+// Calls fn with the changes of every later write, synchronously once the write is published,
+// in the order of subscription. Writes that fail send no changes.
+// It returns a function that ends the subscription
+func (s *SyncDataframe) Subscribe(fn func(ChangeEvent)) func() {
+	return s.observers.add(fn)
+}
+
+// Sends the changes of every later write to the returned channel, which buffers up to size events.
+// Writes wait while the buffer is full.
+// It also returns a function that ends the subscription and closes the channel
+func (s *SyncDataframe) SubscribeChan(size int) (<-chan ChangeEvent, func()) {
+	return subscribeChan(s.Subscribe, size)
+}
+
+// Runs mutate on a new dataframe sharing the columns of the latest snapshot, which replaces the snapshot
+// if mutate returns no error. The shared columns are copied only when mutate changes them.
+// Only one write runs at a time, so the mutations in mutate are atomic.
+// The df becomes read-only once published, so it should not be kept beyond mutate
+func (s *SyncDataframe) Write(mutate func(df *Dataframe) error) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	df := s.Snapshot().shareCols()
+
+	// the changes are held back until the write is published, as a failed write is discarded
+	events := []ChangeEvent{}
+	if len(s.observers.getSubscribers()) > 0 {
+		df.Subscribe(func(event ChangeEvent) { events = append(events, event) })
+	}
+
+	err := mutate(df)
+	if err != nil {
+		return err
+	}
+
+	df.observers = nil
+	df.isReadOnly = true
+	s.mu.Lock()
+	s.current = df
+	s.mu.Unlock()
+
+	for _, event := range events {
+		s.observers.notify(event)
+	}
+
+	return nil
+}
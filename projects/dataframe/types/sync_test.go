@@ -0,0 +1,244 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Readers should run queries on consistent snapshots while writers insert, update and delete concurrently.
+// Run with -race to catch unsynchronised access
+func TestSyncDataframe_concurrentReadsAndWrites(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	s := NewSyncDataframe(df)
+	noOfWriters := 4
+	noOfReaders := 8
+	noOfWrites := 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, noOfWriters * noOfWrites + noOfReaders * noOfWrites)
+
+	for w := 0; w < noOfWriters; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < noOfWrites; i++ {
+				firstName := fmt.Sprintf("Writer%d-%d", w, i)
+				err := s.Write(func(df *Dataframe) error {
+					err := df.Insert([]map[string]interface{}{
+						{"first name": firstName, "last name": "Poe", "age": i, "location": "Gulu"},
+					})
+					if err != nil {
+						return err
+					}
+
+					return df.Update(df.Col("first name").Equals(firstName), map[string]interface{}{"location": "Mbale"})
+				})
+				if err != nil {
+					errs <- err
+					continue
+				}
+
+				err = s.Update(Col("first name").Eq(firstName), map[string]interface{}{"age": i + 100})
+				if err != nil {
+					errs <- err
+				}
+
+				if i % 2 == 0 {
+					err = s.Delete(Col("first name").Eq(firstName))
+					if err != nil {
+						errs <- err
+					}
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < noOfReaders; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < noOfWrites; i++ {
+				snapshot := s.Snapshot()
+				count := snapshot.Count()
+
+				records, err := snapshot.Select("first name", "location").Where(Col("age").Gte(0)).Execute()
+				if err != nil {
+					errs <- err
+					continue
+				}
+
+				if len(records) != count {
+					errs <- fmt.Errorf("snapshot changed while it was read: expected %d records, got %d", count, len(records))
+				}
+
+				for _, record := range records {
+					if record["location"] == "Gulu" {
+						errs <- fmt.Errorf("expected only whole writes to be seen, got %v", record)
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("error is: %s", err)
+	}
+
+	expectedCount := len(dataArray) + noOfWriters * noOfWrites / 2
+	if s.Count() != expectedCount {
+		t.Fatalf("count expected: %d, got %d", expectedCount, s.Count())
+	}
+}
+
+// Snapshots should not change after later writes, and failed writes should leave the latest snapshot unchanged
+func TestSyncDataframe_Snapshot(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	s := NewSyncDataframe(df.WithStrictness(STRICT))
+	before := s.Snapshot()
+	q := s.Select("first name").Where(Col("age").Gt(40))
+
+	err = s.Delete(Col("age").Gt(40))
+	if err != nil {
+		t.Fatalf("delete error is: %s", err)
+	}
+
+	records, err := q.Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if len(records) != 3 || before.Count() != len(dataArray) {
+		t.Fatalf("expected the old snapshot to keep all rows, got %v", records)
+	}
+
+	if s.Count() != len(dataArray) - 3 {
+		t.Fatalf("count expected: %d, got %d", len(dataArray) - 3, s.Count())
+	}
+
+	latest := s.Snapshot()
+	for i, err := range []error{
+		s.Insert([]map[string]interface{}{{"first name": "Tom", "last name": "Poe", "age": "old"}}),
+		s.Update(Col("salary").Gt(0), map[string]interface{}{"age": 1}),
+		s.Write(func(df *Dataframe) error {
+			err := df.Delete(df.Col("age").GreaterThan(0))
+			if err != nil {
+				return err
+			}
+
+			return fmt.Errorf("failed after deleting")
+		}),
+	} {
+		if err == nil {
+			t.Fatalf("write %d: expected an error", i)
+		}
+
+		if s.Snapshot() != latest || s.Count() != len(dataArray) - 3 {
+			t.Fatalf("write %d: expected the snapshot to be unchanged", i)
+		}
+	}
+
+	// snapshots are read-only, the first one included, but their copies can be mutated
+	for i, snapshot := range []*Dataframe{before, latest} {
+		if snapshot.Delete(snapshot.Col("age").GreaterThan(0)) == nil || snapshot.Clear() == nil {
+			t.Fatalf("snapshot %d: expected an error on mutating a snapshot", i)
+		}
+	}
+
+	copied, err := latest.Copy()
+	if err != nil {
+		t.Fatalf("copy error is: %s", err)
+	}
+
+	err = copied.Clear()
+	if err != nil || copied.Count() != 0 || s.Count() != len(dataArray) - 3 {
+		t.Fatalf("expected the copy to be cleared without changing the snapshot, got %v", err)
+	}
+
+	// the dataframe passed to NewSyncDataframe stays writable, apart from the SyncDataframe
+	err = df.Update(df.Col("first name").Equals("John"), map[string]interface{}{"age": 99})
+	if err != nil {
+		t.Fatalf("update error is: %s", err)
+	}
+
+	if before.Col("age").Items()[0] != 30 || df.Col("age").Items()[0] != 99 {
+		t.Fatalf("expected the first snapshot to keep age 30 and df to have 99, got %v and %v",
+			before.Col("age").Items()[0], df.Col("age").Items()[0])
+	}
+
+	err = df.Clear()
+	if err != nil || df.Count() != 0 || before.Count() != len(dataArray) || s.Count() != len(dataArray) - 3 {
+		t.Fatalf("expected df to be cleared without changing the snapshots, got %v", err)
+	}
+
+	// views of a snapshot can be taken from many goroutines at once, as they do not change the snapshot
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			snapshot := s.Snapshot()
+			view, err := snapshot.AsOf(snapshot.Version())
+			if err != nil || view.Count() != len(dataArray) - 3 {
+				t.Errorf("expected a view of %d rows, got %v", len(dataArray) - 3, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Writes should share the columns they do not change with the previous snapshot, and copy the ones they change
+func TestSyncDataframe_WriteSharing(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	s := NewSyncDataframe(df)
+	before := s.Snapshot()
+
+	err = s.Update(Col("first name").Eq("John"), map[string]interface{}{"age": 31})
+	if err != nil {
+		t.Fatalf("update error is: %s", err)
+	}
+
+	after := s.Snapshot()
+	if &after.cols["location"].stringItems[0] != &before.cols["location"].stringItems[0] {
+		t.Fatalf("expected the location column to be shared")
+	}
+
+	if &after.cols["age"].intItems[0] == &before.cols["age"].intItems[0] {
+		t.Fatalf("expected the age column to be copied")
+	}
+
+	if before.Col("age").Items()[0] != 30 || after.Col("age").Items()[0] != 31 {
+		t.Fatalf("ages expected: 30 then 31, got %v then %v", before.Col("age").Items()[0], after.Col("age").Items()[0])
+	}
+
+	err = s.Insert([]map[string]interface{}{{"first name": "Tom", "last name": "Poe", "age": 20, "location": nil}})
+	if err != nil {
+		t.Fatalf("insert error is: %s", err)
+	}
+
+	if before.Count() != len(dataArray) || after.Count() != len(dataArray) || s.Count() != len(dataArray) + 1 {
+		t.Fatalf("expected the appended row only in the latest snapshot, got %d, %d, %d", before.Count(), after.Count(), s.Count())
+	}
+
+	if after.Col("location").Items()[len(dataArray) - 1] != "Kampala" {
+		t.Fatalf("expected the earlier snapshot to keep its locations, got %v", after.Col("location").Items())
+	}
+}
@@ -0,0 +1,292 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A set of mutations on a Dataframe that can be undone as a whole until it is committed.
+// The mutations are applied to the dataframe straight away, so they are visible to readers
+// of the dataframe before the transaction is committed.
+// Only the columns whose existing rows are changed are copied; appended rows are just cut off on rollback
+type transaction struct {
+	df *Dataframe;
+	// the number of rows when the transaction began
+	count int;
+	// copies of the columns whose existing rows have been changed, as they were when the transaction began
+	savedCols map[string]*Column;
+	// the columns that did not exist when the transaction began
+	newCols map[string]struct{};
+	// a copy of the index as it was when the transaction began, only taken when rows are deleted
+	savedIndex map[interface{}]int;
+	// the keys of the rows added by the transaction
+	addedKeys []interface{};
+	// the operations done on the transaction, that describe the version made when it is committed
+	operations []string;
+	// whether the changes to the records are kept for the subscribers of the dataframe
+	isTracked bool;
+	// the changes to the records, sent to the subscribers when the transaction is committed
+	changes []RecordChange;
+	isDone bool;
+}
+
+// Begins a transaction on the dataframe. Mutations done on the transaction can be undone with Rollback
+// until Commit is called. The dataframe should not be mutated directly while the transaction is open.
+// On a read-only dataframe, every method of the transaction returns an error
+func (d *Dataframe) Begin() *transaction {
+	if d.checkIsWritable() == nil {
+		d.initHistory()
+		d.defragmentize()
+		d.normalizeCols(nil)
+	}
+
+	return &transaction{
+		df: d,
+		count: d.Count(),
+		savedCols: map[string]*Column{},
+		newCols: map[string]struct{}{},
+		isTracked: d.hasSubscribers(),
+	}
+}
+
+// Inserts the records into the dataframe, updating the rows whose keys already exist
+func (t *transaction) Insert(records []map[string]interface{}) error {
+	if err := t.checkIsOpen(); err != nil {
+		return err
+	}
+
+	t.operations = append(t.operations, "insert")
+	return t.insert(records)
+}
+
+// Updates the fields of the rows whose values in filter are true with the given values
+func (t *transaction) Update(filter []bool, value map[string]interface{}) error {
+	if err := t.checkIsOpen(); err != nil {
+		return err
+	}
+
+	pkFieldMap := t.df.getPkFieldMap()
+	for field := range value {
+		if _, ok := pkFieldMap[field]; !ok {
+			t.saveCol(field, true)
+		}
+	}
+
+	var changes []RecordChange
+	if t.isTracked {
+		changes = t.df.getChanges(UPDATED, filter)
+	}
+
+	t.operations = append(t.operations, "update")
+	err := t.df.updateRows(filter, value)
+	if err != nil {
+		return err
+	}
+
+	if t.isTracked {
+		t.df.setValuesAfter(changes)
+		t.changes = append(t.changes, changes...)
+	}
+
+	return nil
+}
+
+// Deletes the rows whose values in filter are true
+func (t *transaction) Delete(filter filterType) error {
+	if err := t.checkIsOpen(); err != nil {
+		return err
+	}
+
+	d := t.df
+	if t.savedIndex == nil {
+		// rows are only appended before the first delete, so those at positions below count are the original ones
+		t.savedIndex = make(map[interface{}]int, t.count)
+		for key, row := range d.index {
+			if row < t.count {
+				t.savedIndex[key] = row
+			}
+		}
+	}
+
+	for field := range d.cols {
+		t.saveCol(field, true)
+	}
+
+	if t.isTracked {
+		t.changes = append(t.changes, d.getChanges(DELETED, filter)...)
+	}
+
+	t.operations = append(t.operations, "delete")
+	d.deleteRows(filter)
+
+	return nil
+}
+
+// Inserts the rows of the given dataframes into the dataframe
+func (t *transaction) Merge(dfs ...*Dataframe) error {
+	if err := t.checkIsOpen(); err != nil {
+		return err
+	}
+
+	t.operations = append(t.operations, "merge")
+	for _, df := range dfs {
+		records, err := df.ToArray()
+		if err != nil {
+			return err
+		}
+
+		err = t.insert(records)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Keeps the mutations done on the transaction, as a new version of the dataframe if there were any.
+// The transaction cannot be used afterwards
+func (t *transaction) Commit() error {
+	if err := t.checkIsOpen(); err != nil {
+		return err
+	}
+
+	switch len(t.operations) {
+	case 0:
+	case 1:
+		t.df.commitVersion(t.operations[0], t.changes)
+	default:
+		t.df.commitVersion("transaction: " + strings.Join(t.operations, ", "), t.changes)
+	}
+
+	t.close()
+	return nil
+}
+
+// Undoes the mutations done on the transaction. The transaction cannot be used afterwards
+func (t *transaction) Rollback() error {
+	if err := t.checkIsOpen(); err != nil {
+		return err
+	}
+
+	d := t.df
+	if t.savedIndex != nil {
+		for key := range d.index {
+			delete(d.index, key)
+		}
+
+		for key, row := range t.savedIndex {
+			d.index[key] = row
+		}
+	} else {
+		for _, key := range t.addedKeys {
+			delete(d.index, key)
+		}
+	}
+
+	for name, col := range d.cols {
+		if _, ok := t.newCols[name]; ok {
+			delete(d.cols, name)
+		} else if saved, ok := t.savedCols[name]; ok {
+			// the column is restored in place as queries may hold pointers to it
+			*col = *saved
+		} else {
+			col.truncate(t.count)
+		}
+	}
+
+	t.close()
+	return nil
+}
+
+/*
+* Helpers
+*/
+
+// Inserts the records into the dataframe, saving what is needed to roll them back
+func (t *transaction) insert(records []map[string]interface{}) error {
+	d := t.df
+	d.defragmentize()
+
+	for _, record := range records {
+		key, err := createKey(record, d.pkFields)
+		if err != nil {
+			return fmt.Errorf("failed to create key for %v using field %v", record, d.pkFields)
+		}
+
+		record, err = d.prepareRecord(record)
+		if err != nil {
+			return err
+		}
+
+		row, exists := d.index[key]
+		for field, value := range record {
+			col := d.cols[field]
+			changesRows := exists && row < t.count
+			changesDtype := col != nil && value != nil && (!col.hasDtype || !col.fits(value))
+			t.saveCol(field, changesRows || changesDtype)
+		}
+
+		change := RecordChange{Type: INSERTED, Key: key}
+		if exists && t.isTracked {
+			change = RecordChange{Type: UPDATED, Key: key, Before: d.getRecordAt(row)}
+		}
+
+		if !exists {
+			t.addedKeys = append(t.addedKeys, key)
+		}
+
+		d.setRecord(key, record)
+
+		if t.isTracked {
+			change.After = d.getRecordAt(d.index[key])
+			t.changes = append(t.changes, change)
+		}
+	}
+
+	d.normalizeCols(nil)
+	return nil
+}
+
+// Returns an error if the transaction has already been committed or rolled back
+func (t *transaction) checkIsOpen() error {
+	if t.isDone {
+		return fmt.Errorf("transaction error: the transaction is already committed or rolled back")
+	}
+
+	return t.df.checkIsWritable()
+}
+
+// Marks the transaction as done, releasing the saved state
+func (t *transaction) close() {
+	t.isDone = true
+	t.savedCols = nil
+	t.newCols = nil
+	t.savedIndex = nil
+	t.addedKeys = nil
+	t.operations = nil
+	t.changes = nil
+}
+
+// Records the column as new if it does not exist yet, or saves a copy of it as it was when the transaction began
+// if changesRows is true and it has not been saved already.
+// Until a column is saved, its first count rows are unchanged, so a copy cut at count is the original column
+func (t *transaction) saveCol(name string, changesRows bool) {
+	col, ok := t.df.cols[name]
+	if !ok {
+		t.newCols[name] = struct{}{}
+		return
+	}
+
+	if _, isNew := t.newCols[name]; isNew || !changesRows {
+		return
+	}
+
+	if _, isSaved := t.savedCols[name]; isSaved {
+		return
+	}
+
+	saved := col.copy()
+	saved.truncate(t.count)
+	t.savedCols[name] = saved
+}
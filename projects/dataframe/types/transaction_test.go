@@ -0,0 +1,202 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// Rollback should undo all the mutations done on the transaction, leaving the dataframe as it was when it began
+func TestTransaction_Rollback(t *testing.T)  {
+	type testRecord struct {
+		name string;
+		mutate func(df *Dataframe, tx *transaction) error;
+	}
+
+	newRecords := []map[string]interface{}{
+		{"first name": "Roy", "last name": "Roe", "age": 30.5, "salary": 400},
+		{"first name": "John", "last name": "Doe", "age": 31, "location": "Mbale"},
+		{"first name": "Roy", "last name": "Roe", "age": 32},
+	}
+
+	testData := []testRecord{
+		{
+			name: "insert",
+			mutate: func(df *Dataframe, tx *transaction) error {
+				return tx.Insert(newRecords)
+			},
+		},
+		{
+			name: "update",
+			mutate: func(df *Dataframe, tx *transaction) error {
+				return tx.Update(df.Col("age").GreaterThan(40), map[string]interface{}{"age": 41.5, "salary": 300})
+			},
+		},
+		{
+			name: "delete then insert",
+			mutate: func(df *Dataframe, tx *transaction) error {
+				err := tx.Delete(df.Col("location").Equals("Kampala"))
+				if err != nil {
+					return err
+				}
+
+				return tx.Insert(newRecords)
+			},
+		},
+		{
+			name: "insert, delete then update",
+			mutate: func(df *Dataframe, tx *transaction) error {
+				err := tx.Insert(newRecords[:1])
+				if err != nil {
+					return err
+				}
+
+				err = tx.Delete(df.Col("age").LessThan(31))
+				if err != nil {
+					return err
+				}
+
+				return tx.Update(df.Col("age").GreaterThan(0), map[string]interface{}{"location": "Gulu"})
+			},
+		},
+		{
+			name: "merge",
+			mutate: func(df *Dataframe, tx *transaction) error {
+				other, err := FromArray(newRecords, primaryFields)
+				if err != nil {
+					return err
+				}
+
+				return tx.Merge(other)
+			},
+		},
+	}
+
+	for _, tr := range testData {
+		df, err := FromArray(dataArray, primaryFields)
+		if err != nil {
+			t.Fatalf("%s: df error is: %s", tr.name, err)
+		}
+
+		age := df.Col("age")
+		tx := df.Begin()
+
+		err = tr.mutate(df, tx)
+		if err != nil {
+			t.Fatalf("%s: mutation error is: %s", tr.name, err)
+		}
+
+		err = tx.Rollback()
+		if err != nil {
+			t.Fatalf("%s: rollback error is: %s", tr.name, err)
+		}
+
+		assertIsDataArray(t, tr.name, df)
+
+		if df.Col("age") != age || age.Dtype() != IntType {
+			t.Fatalf("%s: expected the age column to be restored in place as %v, got %v", tr.name, IntType, df.Col("age").Dtype())
+		}
+	}
+}
+
+// Commit should keep the mutations done on the transaction, and the transaction should not be usable afterwards
+func TestTransaction_Commit(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	tx := df.Begin()
+	err = tx.Insert([]map[string]interface{}{{"first name": "Roy", "last name": "Roe", "age": 70}})
+	if err != nil {
+		t.Fatalf("insert error is: %s", err)
+	}
+
+	err = tx.Delete(df.Col("first name").Equals("John"))
+	if err != nil {
+		t.Fatalf("delete error is: %s", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatalf("commit error is: %s", err)
+	}
+
+	expectedKeys := append(append([]string{}, keys[1:]...), "Roy_Roe")
+	if !utils.AreStringSliceEqual(df.Keys(), expectedKeys) {
+		t.Fatalf("keys expected: %v, got %v", expectedKeys, df.Keys())
+	}
+
+	for i, err := range []error{
+		tx.Insert(dataArray),
+		tx.Update(df.Col("age").GreaterThan(0), map[string]interface{}{"age": 1}),
+		tx.Delete(df.Col("age").GreaterThan(0)),
+		tx.Commit(),
+		tx.Rollback(),
+	} {
+		if err == nil {
+			t.Fatalf("operation %d: expected an error on a committed transaction", i)
+		}
+	}
+
+	if df.Count() != len(expectedKeys) {
+		t.Fatalf("count expected: %d, got %d", len(expectedKeys), df.Count())
+	}
+}
+
+// A failed Insert or Merge should leave the dataframe unchanged
+func TestDataframe_InsertIsAtomic(t *testing.T)  {
+	records := []map[string]interface{}{
+		{"first name": "John", "last name": "Doe", "age": 31},
+		{"first name": "Roy", "last name": "Roe", "age": 70, "salary": 400},
+		{"first name": "Tom", "last name": "Poe", "age": "old"},
+	}
+
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	err = df.WithStrictness(STRICT).Insert(records)
+	if err == nil {
+		t.Fatalf("expected an error for a value that does not match its column")
+	}
+
+	assertIsDataArray(t, "insert", df)
+
+	first, err := FromArray(records[:2], primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	second, err := FromArray(records[2:], primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	err = df.Merge(first, second)
+	if err == nil {
+		t.Fatalf("expected an error for a value that does not match its column")
+	}
+
+	assertIsDataArray(t, "merge", df)
+}
+
+// Fails the test if the dataframe does not have exactly the rows and columns of dataArray
+func assertIsDataArray(t *testing.T, name string, df *Dataframe) {
+	if !utils.AreStringSliceEqual(df.Keys(), keys) {
+		t.Fatalf("%s: keys expected: %v, got %v", name, keys, df.Keys())
+	}
+
+	colNames := utils.SortStringSlice(df.ColumnNames(), utils.ASC)
+	if !utils.AreStringSliceEqual(colNames, expectedCols) {
+		t.Fatalf("%s: cols expected: %v, got %v", name, expectedCols, colNames)
+	}
+
+	for _, field := range expectedCols {
+		expected := utils.ExtractFieldFromMapList(dataArray, field)
+		if !utils.AreSliceEqual(df.Col(field).Items(), expected) {
+			t.Fatalf("%s: %s expected: %v, got %v", name, field, expected, df.Col(field).Items())
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// Description of a version of a dataframe, made by one of its mutations
+type Version struct {
+	// the number of the version, 0 being the dataframe as it was before its first mutation
+	Number int
+	// the mutation that made the version i.e. "create", "insert", "update", "delete", "merge", "clear",
+	// or "transaction: " followed by the operations of a transaction e.g. "transaction: insert, delete"
+	Operation string
+	// the number of rows in the version
+	Count int
+	CreatedAt time.Time
+}
+
+// A version together with the columns of the dataframe at that version.
+// The columns share their items with the columns of later versions, until the later versions change
+// the columns, when they copy the items of the changed columns first
+type snapshot struct {
+	Version
+	cols map[string]Column
+}
+
+// Keeps the columns of the last n versions of the dataframe, the current one included, so that AsOf can return them.
+// Every version is listed in History whatever n, but keeping the columns of a version is off by default,
+// as each kept version holds on to the items of every column that later versions change: memory grows with n
+// and with the size of the columns that each mutation changes. The columns of versions made before versioning
+// is turned on are not kept. A value of 0 or less turns versioning off, dropping the kept columns.
+// It returns the same dataframe to allow chaining
+func (d *Dataframe) WithVersioning(n int) *Dataframe {
+	if n <= 0 {
+		d.versioning = 0
+		d.versions = nil
+		return d
+	}
+
+	d.versioning = n
+	if len(d.versions) == 0 {
+		d.initHistory()
+		d.versions = []*snapshot{d.takeSnapshot()}
+	}
+
+	d.pruneVersions()
+	return d
+}
+
+// Returns the number of the latest version of the dataframe
+func (d *Dataframe) Version() int {
+	return d.getLatestVersion().Number
+}
+
+// Returns all the versions of the dataframe, from version 0 to the latest, each made by an Insert, Update,
+// Delete, Merge, Clear or committed transaction. The columns of only the versions kept with WithVersioning
+// can be returned by AsOf
+func (d *Dataframe) History() []Version {
+	if len(d.history) == 0 {
+		return []Version{d.getLatestVersion()}
+	}
+
+	return append([]Version(nil), d.history...)
+}
+
+// Returns a read-only view of the dataframe as it was at the given version, which must be the latest version
+// or one of the versions kept with WithVersioning.
+// The view shares its items with the dataframe, and its mutations return errors
+func (d *Dataframe) AsOf(version int) (*Dataframe, error) {
+	if version < 0 || version > d.Version() {
+		return nil, fmt.Errorf("version error: version %d does not exist, the latest is %d", version, d.Version())
+	}
+
+	if version == d.Version() {
+		return d.getReadOnlyView(), nil
+	}
+
+	if len(d.versions) == 0 {
+		return nil, fmt.Errorf("version error: the columns of version %d are not kept, as versioning is off; turn it on with WithVersioning", version)
+	}
+
+	oldest := d.versions[0].Number
+	if version < oldest {
+		return nil, fmt.Errorf("version error: the columns of version %d are no longer kept, the oldest kept is %d", version, oldest)
+	}
+
+	s := d.versions[version-oldest]
+	view := &Dataframe{
+		pkFields: d.pkFields,
+		cols: make(map[string]*Column, len(s.cols)),
+		index: make(map[interface{}]int, s.Count),
+		strictness: d.strictness,
+		parallelism: d.parallelism,
+		// the history never changes up to the version, so the view can share it
+		history: d.history[:version+1:version+1],
+		isReadOnly: true,
+	}
+
+	for name, col := range s.cols {
+		col := col
+		view.cols[name] = &col
+	}
+
+	err := view.buildIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	return view, nil
+}
+
+/*
+* Helpers
+*/
+
+// Returns the latest version, which is version 0 made by "create" if the dataframe has not been mutated
+func (d *Dataframe) getLatestVersion() Version {
+	if len(d.history) == 0 {
+		return Version{Operation: "create", Count: d.Count()}
+	}
+
+	return d.history[len(d.history)-1]
+}
+
+// Records the dataframe as it is as version 0, if no version has been recorded yet.
+// It is called before the first mutation, so that version 0 has the rows the dataframe was created with
+func (d *Dataframe) initHistory() {
+	if len(d.history) == 0 {
+		d.history = []Version{{Operation: "create", Count: d.Count(), CreatedAt: time.Now()}}
+	}
+}
+
+// Makes a new version of the dataframe, made by the given operation, keeping its columns if versioning is on
+func (d *Dataframe) recordVersion(operation string) {
+	d.initHistory()
+	d.history = append(d.history, Version{Number: len(d.history), Operation: operation, Count: d.Count(), CreatedAt: time.Now()})
+
+	if d.versioning > 0 {
+		d.versions = append(d.versions, d.takeSnapshot())
+		d.pruneVersions()
+	}
+}
+
+// Returns the current state of the dataframe as a snapshot of its latest version.
+// The columns are not copied, but marked as shared so that they are copied before they next change
+func (d *Dataframe) takeSnapshot() *snapshot {
+	cols := make(map[string]Column, len(d.cols))
+	for name, col := range d.cols {
+		col.isShared = true
+		cols[name] = clipColumn(*col)
+	}
+
+	return &snapshot{Version: d.getLatestVersion(), cols: cols}
+}
+
+// Drops the oldest versions beyond the number kept
+func (d *Dataframe) pruneVersions() {
+	if extra := len(d.versions) - d.versioning; extra > 0 {
+		// a new array, as copies of the dataframe may share the old one
+		d.versions = append([]*snapshot(nil), d.versions[extra:]...)
+	}
+}
+
+// Returns a read-only dataframe that shares the columns of this one, which are marked as shared
+// so that they are copied before this dataframe next changes them. The columns of a read-only dataframe
+// are never changed in place, so they are left as they are, as other goroutines may be reading them
+func (d *Dataframe) getReadOnlyView() *Dataframe {
+	if !d.isReadOnly {
+		for _, col := range d.cols {
+			col.isShared = true
+		}
+	}
+
+	view := d.shareCols()
+	view.isReadOnly = true
+	return view
+}
+
+// Returns a dataframe with a copy of the index and the same settings, history and versions as this one, whose columns
+// share their items with the columns of this one. The shared columns are copied before the new dataframe
+// changes them, so this dataframe is never changed through it. This dataframe must not change
+// its own columns in place afterwards, unless they are marked as shared
+func (d *Dataframe) shareCols() *Dataframe {
+	newDf := &Dataframe{
+		pkFields: d.pkFields,
+		cols: make(map[string]*Column, len(d.cols)),
+		index: make(map[interface{}]int, len(d.index)),
+		strictness: d.strictness,
+		parallelism: d.parallelism,
+		versioning: d.versioning,
+		// the history and versions never change, so the new dataframe can share them, but not append to the same arrays
+		history: d.history[:len(d.history):len(d.history)],
+		versions: d.versions[:len(d.versions):len(d.versions)],
+	}
+
+	for key, row := range d.index {
+		newDf.index[key] = row
+	}
+
+	for name, col := range d.cols {
+		shared := clipColumn(*col)
+		shared.isShared = true
+		newDf.cols[name] = &shared
+	}
+
+	return newDf
+}
+
+// Returns an error if the dataframe is read-only, like a view of a version
+func (d *Dataframe) checkIsWritable() error {
+	if d.isReadOnly {
+		return fmt.Errorf("version error: the dataframe is a read-only view")
+	}
+
+	return nil
+}
+
+// Returns the column with the capacities of its slices cut to their lengths,
+// so that appending to it never writes to the arrays it shares with other versions
+func clipColumn(col Column) Column {
+	col.intItems = col.intItems[:len(col.intItems):len(col.intItems)]
+	col.floatItems = col.floatItems[:len(col.floatItems):len(col.floatItems)]
+	col.stringItems = col.stringItems[:len(col.stringItems):len(col.stringItems)]
+	col.boolItems = col.boolItems[:len(col.boolItems):len(col.boolItems)]
+	col.timeItems = col.timeItems[:len(col.timeItems):len(col.timeItems)]
+	col.objectItems = col.objectItems[:len(col.objectItems):len(col.objectItems)]
+	col.validity = col.validity[:len(col.validity):len(col.validity)]
+
+	return col
+}
@@ -0,0 +1,290 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/learn-along/learn-go/projects/dataframe/utils"
+)
+
+// Each mutation should make a new version, and AsOf should return the dataframe as it was at any kept version
+func TestDataframe_AsOf(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	df.WithVersioning(10)
+
+	other, err := FromArray([]map[string]interface{}{
+		{"first name": "Ann", "last name": "Poe", "age": 21, "location": "Gulu"},
+	}, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	mutations := []func() error{
+		func() error {
+			return df.Insert([]map[string]interface{}{{"first name": "Tom", "last name": "Poe", "age": 70, "location": "Mbale"}})
+		},
+		func() error {
+			return df.Update(df.Col("age").GreaterThan(50), map[string]interface{}{"age": 51.5})
+		},
+		func() error {
+			return df.Delete(df.Col("location").Equals("Kampala"))
+		},
+		func() error {
+			return df.Merge(other)
+		},
+		func() error {
+			tx := df.Begin()
+			err := tx.Insert([]map[string]interface{}{{"first name": "Ben", "last name": "Poe", "age": 5}})
+			if err != nil {
+				return err
+			}
+
+			err = tx.Delete(df.Col("first name").Equals("Ann"))
+			if err != nil {
+				return err
+			}
+
+			return tx.Commit()
+		},
+	}
+
+	expectedOperations := []string{"create", "insert", "update", "delete", "merge", "transaction: insert, delete"}
+	states := []map[string][]interface{}{}
+	state := func() map[string][]interface{} {
+		columns := map[string][]interface{}{}
+		for _, name := range df.ColumnNames() {
+			columns[name] = df.Col(name).Items()
+		}
+
+		return columns
+	}
+
+	keysAtVersion := [][]string{df.Keys()}
+	states = append(states, state())
+
+	for i, mutate := range mutations {
+		err = mutate()
+		if err != nil {
+			t.Fatalf("mutation %d: error is: %s", i, err)
+		}
+
+		keysAtVersion = append(keysAtVersion, df.Keys())
+		states = append(states, state())
+	}
+
+	// a rolled back transaction makes no version
+	tx := df.Begin()
+	err = tx.Update(df.Col("age").GreaterThan(0), map[string]interface{}{"age": 0})
+	if err != nil {
+		t.Fatalf("update error is: %s", err)
+	}
+
+	err = tx.Rollback()
+	if err != nil {
+		t.Fatalf("rollback error is: %s", err)
+	}
+
+	history := df.History()
+	if len(history) != len(expectedOperations) || df.Version() != len(expectedOperations) - 1 {
+		t.Fatalf("expected %d versions, got %v", len(expectedOperations), history)
+	}
+
+	for version, expectedOperation := range expectedOperations {
+		if history[version].Number != version || history[version].Operation != expectedOperation {
+			t.Fatalf("version %d: expected %s, got %v", version, expectedOperation, history[version])
+		}
+
+		if history[version].Count != len(keysAtVersion[version]) {
+			t.Fatalf("version %d: count expected: %d, got %d", version, len(keysAtVersion[version]), history[version].Count)
+		}
+
+		view, err := df.AsOf(version)
+		if err != nil {
+			t.Fatalf("version %d: as of error is: %s", version, err)
+		}
+
+		if !utils.AreStringSliceEqual(view.Keys(), keysAtVersion[version]) {
+			t.Fatalf("version %d: keys expected: %v, got %v", version, keysAtVersion[version], view.Keys())
+		}
+
+		if len(view.ColumnNames()) != len(states[version]) {
+			t.Fatalf("version %d: cols expected: %v, got %v", version, states[version], view.ColumnNames())
+		}
+
+		for name, items := range states[version] {
+			if !utils.AreSliceEqual(view.Col(name).Items(), items) {
+				t.Fatalf("version %d: %s expected: %v, got %v", version, name, items, view.Col(name).Items())
+			}
+		}
+	}
+
+	for _, version := range []int{-1, len(expectedOperations)} {
+		_, err = df.AsOf(version)
+		if err == nil {
+			t.Fatalf("expected an error for version %d", version)
+		}
+	}
+}
+
+// Versions should share the items of the columns that did not change, and views should not be mutable
+func TestDataframe_versionSharing(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	df.WithVersioning(10)
+
+	err = df.Update(df.Col("first name").Equals("John"), map[string]interface{}{"age": 31})
+	if err != nil {
+		t.Fatalf("update error is: %s", err)
+	}
+
+	before, after := df.versions[0].cols, df.versions[1].cols
+	if &before["location"].stringItems[0] != &after["location"].stringItems[0] {
+		t.Fatalf("expected the unchanged location column to be shared")
+	}
+
+	if &before["age"].intItems[0] == &after["age"].intItems[0] {
+		t.Fatalf("expected the updated age column to be copied")
+	}
+
+	err = df.Insert([]map[string]interface{}{{"first name": "Tom", "last name": "Poe", "age": 70, "location": "Mbale"}})
+	if err != nil {
+		t.Fatalf("insert error is: %s", err)
+	}
+
+	view, err := df.AsOf(1)
+	if err != nil {
+		t.Fatalf("as of error is: %s", err)
+	}
+
+	for i, err := range []error{
+		view.Insert(dataArray),
+		view.Update(view.Col("age").GreaterThan(0), map[string]interface{}{"age": 1}),
+		view.Delete(view.Col("age").GreaterThan(0)),
+		view.Merge(df),
+		view.Clear(),
+		view.Begin().Insert(dataArray),
+		view.Begin().Commit(),
+	} {
+		if err == nil {
+			t.Fatalf("mutation %d: expected an error on a read-only view", i)
+		}
+	}
+
+	expectedAges := []interface{}{31, 50, 19, 34, 45, 60}
+	if !utils.AreSliceEqual(view.Col("age").Items(), expectedAges) {
+		t.Fatalf("ages expected: %v, got %v", expectedAges, view.Col("age").Items())
+	}
+
+	// Clear makes a version of its own, and the views of earlier versions keep their rows
+	err = df.Clear()
+	if err != nil {
+		t.Fatalf("clear error is: %s", err)
+	}
+
+	history := df.History()
+	if latest := history[len(history)-1]; latest.Operation != "clear" || latest.Count != 0 {
+		t.Fatalf("expected a clear version with no rows, got %v", latest)
+	}
+
+	view, err = df.AsOf(df.Version() - 1)
+	if err != nil {
+		t.Fatalf("as of error is: %s", err)
+	}
+
+	if view.Count() != len(dataArray) + 1 {
+		t.Fatalf("expected %d rows before the clear, got %d", len(dataArray) + 1, view.Count())
+	}
+}
+
+// Every version should be listed in History, but the columns of versions should only be kept
+// once versioning is turned on, and then only for the given number of versions
+func TestDataframe_WithVersioning(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	ages := df.cols["age"].intItems
+	for i := 0; i < 3; i++ {
+		err = df.Update(df.Col("first name").Equals("John"), map[string]interface{}{"age": 40 + i})
+		if err != nil {
+			t.Fatalf("update error is: %s", err)
+		}
+	}
+
+	if &df.cols["age"].intItems[0] != &ages[0] {
+		t.Fatalf("expected the updates to change the age column in place without versioning")
+	}
+
+	history := df.History()
+	if df.Version() != 3 || len(history) != 4 || history[0].Operation != "create" || history[3].Operation != "update" {
+		t.Fatalf("expected versions 0 to 3, got %v", history)
+	}
+
+	for i, version := range history {
+		if version.Number != i || version.Count != len(dataArray) {
+			t.Fatalf("expected version %d of %d rows, got %v", i, len(dataArray), version)
+		}
+	}
+
+	_, err = df.AsOf(2)
+	if err == nil {
+		t.Fatalf("expected an error for a version that is not kept")
+	}
+
+	latest, err := df.AsOf(3)
+	if err != nil {
+		t.Fatalf("as of error is: %s", err)
+	}
+
+	err = df.Update(df.Col("first name").Equals("John"), map[string]interface{}{"age": 50})
+	if err != nil {
+		t.Fatalf("update error is: %s", err)
+	}
+
+	if latest.Col("age").Items()[0] != 42 || latest.Clear() == nil {
+		t.Fatalf("expected the view of version 3 to keep its ages and be read-only, got %v", latest.Col("age").Items())
+	}
+
+	df.WithVersioning(2)
+	for i := 0; i < 3; i++ {
+		err = df.Delete(df.Col("age").Equals(df.Col("age").Items()[df.Count()-1]))
+		if err != nil {
+			t.Fatalf("delete error is: %s", err)
+		}
+	}
+
+	history = df.History()
+	if len(history) != 8 || history[7].Operation != "delete" || history[7].Count != len(dataArray) - 3 {
+		t.Fatalf("expected versions 0 to 7, got %v", history)
+	}
+
+	if len(df.versions) != 2 || df.versions[0].Number != 6 || df.versions[1].Number != 7 {
+		t.Fatalf("expected the columns of versions 6 and 7 to be kept, got %d versions", len(df.versions))
+	}
+
+	_, err = df.AsOf(5)
+	if err == nil {
+		t.Fatalf("expected an error for a version that is no longer kept")
+	}
+
+	view, err := df.AsOf(6)
+	if err != nil || view.Count() != len(dataArray) - 2 {
+		t.Fatalf("expected version 6 to have %d rows, got %v, %v", len(dataArray) - 2, view, err)
+	}
+
+	if view.Version() != 6 || len(view.History()) != 7 {
+		t.Fatalf("expected the view to have the history up to version 6, got %v", view.History())
+	}
+
+	df.WithVersioning(0)
+	if len(df.History()) != 8 || df.versions != nil {
+		t.Fatalf("expected the kept columns to be dropped but not the history, got %v", df.History())
+	}
+}
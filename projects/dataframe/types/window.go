@@ -0,0 +1,746 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// The frame bound that stretches to the start (or end) of the partition
+const UNBOUNDED = -1
+
+var (
+	// the position of each row in its partition, starting at 1
+	ROW_NUMBER = windowFunc{name: "row_number", fn: getRowNumbers}
+	// the rank of each row in its partition, with gaps after rows that have the same order values
+	RANK = windowFunc{name: "rank", fn: getRanks}
+	// the rank of each row in its partition, without gaps after rows that have the same order values
+	DENSE_RANK = windowFunc{name: "dense_rank", fn: getDenseRanks}
+	// LAG(offset) is the value of the row offset rows before each row in its partition
+	LAG = getLag
+	// LEAD(offset) is the value of the row offset rows after each row in its partition
+	LEAD = getLead
+	// aggregates of the values in the frame of each row,
+	// which by default stretches from the start of the partition to the current row
+	CUMSUM = getFramedAggregate("cumsum", getSum)
+	CUMMEAN = getFramedAggregate("cummean", getMean)
+	CUMMAX = getFramedAggregate("cummax", getMax)
+	CUMMIN = getFramedAggregate("cummin", getMin)
+)
+
+// Function computing a value for every row of a partition, and the name it gives to its results
+type windowFunc struct {
+	name string
+	fn func(p *windowPartition) []interface{}
+}
+
+// The partitioning and ordering of the rows for a window
+type windowOption struct {
+	partitionBy []string
+	orderBy []sortOption
+}
+
+// The rows over which a window function is computed for a given column
+type windowSpec struct {
+	field string
+	partitionBy []string
+	orderBy []sortOption
+	preceding int
+	following int
+	// the least number of non-nil values a frame must have for the aggregate to be computed, else it is nil
+	minPeriods int
+	// any error met while building the window, returned when the window function is computed
+	err error
+}
+
+// A window function computed for a given window, and the name of the column to hold its results
+type windowExpr struct {
+	spec windowSpec
+	fn windowFunc
+	name string
+}
+
+// The rows of a single partition in the order of the window
+type windowPartition struct {
+	// the values of the column, in the order of the window
+	values []interface{}
+	// true for a row if its order values are the same as those of the row before it
+	isPeer []bool
+	// the number of rows before and after each row that make up its frame; UNBOUNDED for no limit
+	preceding int
+	following int
+	minPeriods int
+}
+
+// Returns a window option that splits the rows into partitions that have the same values for the given fields
+func PartitionBy(fields ...string) windowOption {
+	return windowOption{partitionBy: fields}
+}
+
+// Returns a window option that orders the rows of each partition by the given sort options
+func OrderBy(options ...sortOption) windowOption {
+	return windowOption{orderBy: options}
+}
+
+// Returns a copy of the window whose frame is made up of the given number of rows before and after each row.
+// UNBOUNDED stretches the frame to the start or the end of the partition
+func (w windowSpec) Rows(preceding int, following int) windowSpec {
+	if preceding < UNBOUNDED || following < UNBOUNDED {
+		w.err = fmt.Errorf("window error: invalid frame of %d preceding and %d following rows", preceding, following)
+	}
+
+	w.preceding = preceding
+	w.following = following
+	return w
+}
+
+// Returns the window function computed over this window, whose results are held in a new column
+// named after this column and the function e.g. "sales_cumsum"
+func (w windowSpec) Apply(fn windowFunc) windowExpr {
+	return windowExpr{spec: w, fn: fn, name: fmt.Sprintf("%s_%s", w.field, fn.name)}
+}
+
+// Returns a copy of the window expression whose results are held in a column of the given name
+func (e windowExpr) As(name string) windowExpr {
+	e.name = name
+	return e
+}
+
+// Returns a copy of the window expression whose aggregates are nil for frames that have fewer than n non-nil values.
+// It applies only to aggregates such as CUMSUM, Rolling and Expanding
+func (e windowExpr) MinPeriods(n int) windowExpr {
+	e.spec.minPeriods = n
+	return e
+}
+
+// Returns a copy of the window expression whose frame is centred on each row, instead of ending at it.
+// If the frame has an even number of rows, it has one more row before the row than after it.
+// Frames that are unbounded are not changed
+func (e windowExpr) Center() windowExpr {
+	if e.spec.preceding == UNBOUNDED || e.spec.following == UNBOUNDED {
+		return e
+	}
+
+	size := e.spec.preceding + e.spec.following + 1
+	e.spec.following = (size - 1) / 2
+	e.spec.preceding = size - 1 - e.spec.following
+	return e
+}
+
+// Adds a column for each of the window expressions, replacing any column of the same name
+func (d *Dataframe) applyWindows(exprs []windowExpr) error {
+	names := make(map[string]struct{}, len(exprs))
+	for _, expr := range exprs {
+		if _, ok := names[expr.name]; ok {
+			return fmt.Errorf("window error: more than one window expression is named '%s'; rename them with As", expr.name)
+		}
+		names[expr.name] = struct{}{}
+	}
+
+	for _, expr := range exprs {
+		if expr.spec.err != nil {
+			return expr.spec.err
+		}
+
+		col, err := d.getWindowColumn(expr.spec)
+		if err != nil {
+			return err
+		}
+
+		newCol := newUntypedColumn(expr.name)
+
+		// FIXME: concurrency possible as the partitions are independent
+		for _, rows := range d.getWindowPartitions(expr.spec) {
+			partition := windowPartition{
+				values: make([]interface{}, len(rows)),
+				isPeer: make([]bool, len(rows)),
+				preceding: expr.spec.preceding,
+				following: expr.spec.following,
+				minPeriods: expr.spec.minPeriods,
+			}
+
+			for i, row := range rows {
+				partition.values[i] = col.get(row)
+				partition.isPeer[i] = i > 0 && d.compareRows(rows[i-1], row, expr.spec.orderBy) == 0
+			}
+
+			for i, value := range expr.fn.fn(&partition) {
+				newCol.insert(rows[i], value)
+			}
+		}
+
+		newCol.grow(d.Count() - newCol.Len())
+		d.cols[expr.name] = newCol
+	}
+
+	return nil
+}
+
+/*
+* Helpers
+*/
+
+// Returns the column, the partition fields and the order fields of the window
+func (w windowSpec) getFields() []string {
+	fields := append([]string{w.field}, w.partitionBy...)
+	for _, opt := range w.orderBy {
+		for field := range opt {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// Gets the column of the window, checking that the partition and order fields exist
+func (d *Dataframe) getWindowColumn(spec windowSpec) (*Column, error) {
+	for _, field := range spec.getFields() {
+		if _, ok := d.cols[field]; !ok {
+			return nil, fmt.Errorf("window error: column '%s' not found", field)
+		}
+	}
+
+	return d.cols[spec.field], nil
+}
+
+// Splits the rows into partitions, in the order in which they first appear,
+// each partition holding its rows in the order of the window
+func (d *Dataframe) getWindowPartitions(spec windowSpec) [][]int {
+	partitions := [][]int{}
+	positions := map[string]int{}
+	values := make([]string, len(spec.partitionBy))
+
+	for _, row := range d.getIndicesInOrder() {
+		for i, field := range spec.partitionBy {
+			values[i] = fmt.Sprintf("%v", d.cols[field].get(row))
+		}
+
+		key := strings.Join(values, "\x00")
+		position, ok := positions[key]
+		if !ok {
+			position = len(partitions)
+			positions[key] = position
+			partitions = append(partitions, []int{})
+		}
+
+		partitions[position] = append(partitions[position], row)
+	}
+
+	if len(spec.orderBy) > 0 {
+		for _, rows := range partitions {
+			sort.SliceStable(rows, func(i, j int) bool {
+				return d.compareRows(rows[i], rows[j], spec.orderBy) < 0
+			})
+		}
+	}
+
+	return partitions
+}
+
+// Compares two rows by the values of the sort options' fields, returning -1, 0 or 1
+func (d *Dataframe) compareRows(first int, second int, options []sortOption) int {
+	for _, opt := range options {
+		for field, order := range opt {
+			col := d.cols[field]
+			result := compareValues(col.get(first), col.get(second))
+			if order == DESC {
+				result = -result
+			}
+
+			if result != 0 {
+				return result
+			}
+		}
+	}
+
+	return 0
+}
+
+// Numbers the rows of the partition from 1
+func getRowNumbers(p *windowPartition) []interface{} {
+	results := make([]interface{}, len(p.values))
+	for i := range results {
+		results[i] = i + 1
+	}
+
+	return results
+}
+
+// Ranks the rows of the partition, rows with the same order values sharing the rank of the first of them
+func getRanks(p *windowPartition) []interface{} {
+	results := make([]interface{}, len(p.values))
+	for i := range results {
+		if p.isPeer[i] {
+			results[i] = results[i-1]
+		} else {
+			results[i] = i + 1
+		}
+	}
+
+	return results
+}
+
+// Ranks the rows of the partition, rows with the same order values sharing a rank and the ranks having no gaps
+func getDenseRanks(p *windowPartition) []interface{} {
+	results := make([]interface{}, len(p.values))
+	rank := 0
+
+	for i := range results {
+		if !p.isPeer[i] {
+			rank++
+		}
+		results[i] = rank
+	}
+
+	return results
+}
+
+// Returns the window function to get the value of the row offset rows before each row, or nil if there is none
+func getLag(offset int) windowFunc {
+	return windowFunc{
+		name: fmt.Sprintf("lag_%d", offset),
+		fn: func(p *windowPartition) []interface{} {
+			return shiftValues(p.values, offset)
+		},
+	}
+}
+
+// Returns the window function to get the value of the row offset rows after each row, or nil if there is none
+func getLead(offset int) windowFunc {
+	return windowFunc{
+		name: fmt.Sprintf("lead_%d", offset),
+		fn: func(p *windowPartition) []interface{} {
+			return shiftValues(p.values, -offset)
+		},
+	}
+}
+
+// Shifts the values forward by offset positions (backwards if negative), filling the gaps with nil
+func shiftValues(values []interface{}, offset int) []interface{} {
+	results := make([]interface{}, len(values))
+	for i := range results {
+		if source := i - offset; source >= 0 && source < len(values) {
+			results[i] = values[source]
+		}
+	}
+
+	return results
+}
+
+// Returns the name of the window function aggregating frames of the given kind e.g. "rolling_sum".
+// Aggregates that are not built in are left out of the name e.g. "rolling"
+func getFramedName(kind string, agg aggregateFunc) string {
+	if name := getAggregateName(agg); name != "" {
+		return fmt.Sprintf("%s_%s", kind, name)
+	}
+
+	return kind
+}
+
+// Returns the window function to aggregate the values in the frame of each row.
+// The built-in aggregates in runningAggregates are kept up to date as the frame slides over the partition,
+// so each row costs about the same whatever the size of its frame. Any other aggregate is recomputed
+// over the frame of every row
+func getFramedAggregate(name string, agg aggregateFunc) windowFunc {
+	return windowFunc{
+		name: name,
+		fn: func(p *windowPartition) []interface{} {
+			running, result := newRunningAggregate(agg, p.values)
+			if running == nil {
+				return getReaggregated(p, agg)
+			}
+
+			results := make([]interface{}, len(p.values))
+			start, end := 0, 0
+
+			for i := range results {
+				frameStart, frameEnd := getFrame(i, len(p.values), p.preceding, p.following)
+				for ; end < frameEnd; end++ {
+					running.add(end)
+				}
+
+				for ; start < frameStart; start++ {
+					running.remove(start)
+				}
+
+				if running.count >= p.minPeriods {
+					results[i] = result(running, start, end)
+				}
+			}
+
+			return results
+		},
+	}
+}
+
+// Aggregates the values in the frame of each row afresh
+func getReaggregated(p *windowPartition, agg aggregateFunc) []interface{} {
+	results := make([]interface{}, len(p.values))
+	for i := range results {
+		start, end := getFrame(i, len(p.values), p.preceding, p.following)
+		frame := p.values[start:end]
+
+		if getCountNonNull(frame).(int) >= p.minPeriods {
+			results[i] = agg(frame)
+		}
+	}
+
+	return results
+}
+
+// Returns the start and the (exclusive) end of the frame of the row at the given position
+func getFrame(position int, count int, preceding int, following int) (int, int) {
+	start, end := 0, count
+
+	if preceding != UNBOUNDED && position-preceding > start {
+		start = position - preceding
+	}
+
+	if following != UNBOUNDED && position+following+1 < end {
+		end = position + following + 1
+	}
+
+	if start > end {
+		start = end
+	}
+
+	return start, end
+}
+
+// The state of an aggregate of the values of a frame, updated as values enter the frame at its end
+// and leave it at its start
+type runningAggregate struct {
+	values []interface{}
+	// the values as float64, set only if all the values that are not nil are plain numbers
+	numbers []float64
+	// whether all the values that are not nil are strings
+	isStrings bool
+	// the number of values in the frame that are not nil
+	count int
+	// the sum of the numbers in the frame, kept with Neumaier's compensated summation, so that the rounding
+	// errors of large numbers that have left the frame do not swamp the sum of the rest
+	sum float64
+	compensation float64
+	// the mean of the numbers in the frame and the sum of their squared deviations from it,
+	// kept with Welford's method which is steadier than a sum of squares
+	mean float64
+	m2 float64
+	// the largest m2 since the mean and m2 were last computed afresh
+	peakM2 float64
+	// the positions of the values in the frame that are not nil, in order, from the head onwards
+	nonNil []int
+	nonNilHead int
+	// the number of times each distinct value is in the frame, only kept for COUNT_DISTINCT
+	distinct map[interface{}]int
+	// the positions of the values in the frame that may yet be the maximum (or minimum) of a later frame,
+	// from the current maximum (or minimum) onwards. Items before the heads have left the frame
+	maxes []int
+	maxHead int
+	mins []int
+	minHead int
+}
+
+// An aggregate that can be kept up to date as the frame slides, and what it needs of the values
+type runningAggregateFunc struct {
+	fn aggregateFunc
+	// whether all the values that are not nil must be plain numbers
+	needsNumbers bool
+	// whether the values may instead all be strings, compared as strings
+	allowsStrings bool
+	// whether the number of times each distinct value is in the frame is needed
+	needsDistinct bool
+	// returns the aggregate of the frame made up of the values from start up to (but not including) end
+	result func(r *runningAggregate, start int, end int) interface{}
+}
+
+// The aggregates that are kept up to date as the frame slides. They give the same results as
+// their aggregateFunc functions within rounding errors, even after large numbers leave the frame.
+// The aggregateFunc functions are used instead if the values are not what they need.
+// MEDIAN, PERCENTILE, MODE and COLLECT are left out, as they need all the values of each frame
+var runningAggregates = []runningAggregateFunc{
+	{fn: getSum, needsNumbers: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		if r.count == 0 {
+			return nil
+		}
+
+		return r.sum + r.compensation
+	}},
+	{fn: getMean, needsNumbers: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		if r.count == 0 {
+			return nil
+		}
+
+		return (r.sum + r.compensation) / float64(r.count)
+	}},
+	{fn: getMax, needsNumbers: true, allowsStrings: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		return r.getExtreme(r.maxes, r.maxHead)
+	}},
+	{fn: getMin, needsNumbers: true, allowsStrings: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		return r.getExtreme(r.mins, r.minHead)
+	}},
+	{fn: getRange, needsNumbers: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		if r.count == 0 {
+			return nil
+		}
+
+		return r.numbers[r.maxes[r.maxHead]] - r.numbers[r.mins[r.minHead]]
+	}},
+	{fn: getVariance, needsNumbers: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		return r.getVariance(0)
+	}},
+	{fn: getSampleVariance, needsNumbers: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		return r.getVariance(1)
+	}},
+	{fn: getStdDev, needsNumbers: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		return getSquareRoot(r.getVariance(0))
+	}},
+	{fn: getSampleStdDev, needsNumbers: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		return getSquareRoot(r.getVariance(1))
+	}},
+	{fn: getCount, result: func(r *runningAggregate, start int, end int) interface{} {
+		return end - start
+	}},
+	{fn: getCountNonNull, result: func(r *runningAggregate, start int, end int) interface{} {
+		return r.count
+	}},
+	{fn: getCountDistinct, needsDistinct: true, result: func(r *runningAggregate, start int, end int) interface{} {
+		return len(r.distinct)
+	}},
+	{fn: getFirst, result: func(r *runningAggregate, start int, end int) interface{} {
+		if r.count == 0 {
+			return nil
+		}
+
+		return r.values[r.nonNil[r.nonNilHead]]
+	}},
+	{fn: getLast, result: func(r *runningAggregate, start int, end int) interface{} {
+		if r.count == 0 {
+			return nil
+		}
+
+		return r.values[r.nonNil[len(r.nonNil)-1]]
+	}},
+}
+
+// Returns the running state of the aggregate for the values, and the function returning its result for a frame.
+// It returns a nil state if the aggregate is not in runningAggregates or the values are not what it needs
+func newRunningAggregate(agg aggregateFunc, values []interface{}) (*runningAggregate, func(r *runningAggregate, start int, end int) interface{}) {
+	for _, running := range runningAggregates {
+		if !isSameFunc(agg, running.fn) {
+			continue
+		}
+
+		r := &runningAggregate{values: values}
+		if running.needsDistinct {
+			r.distinct = map[interface{}]int{}
+		}
+
+		if running.needsNumbers {
+			r.numbers, r.isStrings = getRunningValues(values)
+			if r.numbers == nil && !(running.allowsStrings && r.isStrings) {
+				return nil, nil
+			}
+		}
+
+		return r, running.result
+	}
+
+	return nil, nil
+}
+
+// Returns the values as float64 if all those that are not nil are plain numbers i.e. ints or float64 values,
+// and whether all those that are not nil are strings
+func getRunningValues(values []interface{}) ([]float64, bool) {
+	numbers := make([]float64, len(values))
+	isNumbers, isStrings := true, true
+
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+		case int:
+			numbers[i] = float64(v)
+		case int8:
+			numbers[i] = float64(v)
+		case int16:
+			numbers[i] = float64(v)
+		case int32:
+			numbers[i] = float64(v)
+		case int64:
+			numbers[i] = float64(v)
+		case float64:
+			numbers[i] = v
+		default:
+			isNumbers = false
+		}
+
+		if _, ok := v.(string); v != nil && !ok {
+			isStrings = false
+		}
+	}
+
+	if !isNumbers {
+		return nil, isStrings
+	}
+
+	return numbers, isStrings
+}
+
+// Adds the value at the given position, the one after the end of the frame, to the frame
+func (r *runningAggregate) add(position int) {
+	if r.values[position] == nil {
+		return
+	}
+
+	r.count++
+	r.nonNil = append(r.nonNil, position)
+	if r.distinct != nil {
+		r.distinct[getDistinctKey(r.values[position])]++
+	}
+
+	if r.numbers != nil {
+		number := r.numbers[position]
+		r.addToSum(number)
+
+		deviation := number - r.mean
+		r.mean += deviation / float64(r.count)
+		r.m2 += deviation * (number - r.mean)
+		r.peakM2 = math.Max(r.peakM2, r.m2)
+	}
+
+	if r.numbers != nil || r.isStrings {
+		// values that can no longer be the maximum (or minimum) while this one is in the frame are dropped
+		for len(r.maxes) > r.maxHead && r.compare(r.maxes[len(r.maxes)-1], position) <= 0 {
+			r.maxes = r.maxes[:len(r.maxes)-1]
+		}
+		r.maxes = append(r.maxes, position)
+
+		for len(r.mins) > r.minHead && r.compare(r.mins[len(r.mins)-1], position) >= 0 {
+			r.mins = r.mins[:len(r.mins)-1]
+		}
+		r.mins = append(r.mins, position)
+	}
+}
+
+// Removes the value at the given position, the first of the frame, from the frame
+func (r *runningAggregate) remove(position int) {
+	if r.values[position] == nil {
+		return
+	}
+
+	r.count--
+	r.nonNilHead++
+	if r.distinct != nil {
+		key := getDistinctKey(r.values[position])
+		r.distinct[key]--
+		if r.distinct[key] == 0 {
+			delete(r.distinct, key)
+		}
+	}
+
+	if r.numbers != nil {
+		number := r.numbers[position]
+		r.addToSum(-number)
+
+		if r.count == 0 {
+			// start afresh, dropping any rounding errors
+			r.sum, r.compensation, r.mean, r.m2, r.peakM2 = 0, 0, 0, 0, 0
+		} else {
+			deviation := number - r.mean
+			r.mean -= deviation / float64(r.count)
+			r.m2 -= deviation * (number - r.mean)
+
+			// once large numbers have left the frame, their rounding errors can swamp the m2 of the rest
+			if r.m2 < r.peakM2 * 1e-6 {
+				r.computeMoments()
+			}
+		}
+	}
+
+	if r.maxHead < len(r.maxes) && r.maxes[r.maxHead] == position {
+		r.maxHead++
+	}
+
+	if r.minHead < len(r.mins) && r.mins[r.minHead] == position {
+		r.minHead++
+	}
+}
+
+// Adds the number to the sum, keeping the low-order bits lost to rounding in the compensation
+func (r *runningAggregate) addToSum(number float64) {
+	total := r.sum + number
+	if math.Abs(r.sum) >= math.Abs(number) {
+		r.compensation += (r.sum - total) + number
+	} else {
+		r.compensation += (number - total) + r.sum
+	}
+
+	r.sum = total
+}
+
+// Compares the values at the two positions, returning -1, 0 or 1
+func (r *runningAggregate) compare(first int, second int) int {
+	if r.numbers != nil {
+		switch {
+		case r.numbers[first] < r.numbers[second]:
+			return -1
+		case r.numbers[first] > r.numbers[second]:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(r.values[first].(string), r.values[second].(string))
+}
+
+// Returns the value at the head of the maxes or mins, as float64 for numbers, or nil if the frame has no values
+func (r *runningAggregate) getExtreme(positions []int, head int) interface{} {
+	if head >= len(positions) {
+		return nil
+	}
+
+	if r.numbers != nil {
+		return r.numbers[positions[head]]
+	}
+
+	return r.values[positions[head]]
+}
+
+// Returns the variance of the numbers in the frame, dividing the sum of squared deviations by the count less ddof,
+// or nil if the count is not more than ddof
+func (r *runningAggregate) getVariance(ddof int) interface{} {
+	if r.count <= ddof {
+		return nil
+	}
+
+	// removing numbers may leave a tiny negative rounding error
+	return math.Max(r.m2, 0) / float64(r.count-ddof)
+}
+
+// Computes the mean and m2 of the numbers in the frame afresh
+func (r *runningAggregate) computeMoments() {
+	positions := r.nonNil[r.nonNilHead:]
+
+	r.mean = 0
+	for _, position := range positions {
+		r.mean += r.numbers[position]
+	}
+	r.mean /= float64(len(positions))
+
+	r.m2 = 0
+	for _, position := range positions {
+		r.m2 += (r.numbers[position] - r.mean) * (r.numbers[position] - r.mean)
+	}
+
+	r.peakM2 = r.m2
+}
+
+// Returns the square root of the variance, or nil if it is nil
+func getSquareRoot(variance interface{}) interface{} {
+	if variance == nil {
+		return nil
+	}
+
+	return math.Sqrt(variance.(float64))
+}
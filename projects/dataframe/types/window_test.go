@@ -0,0 +1,374 @@
+package types
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// Window should add a column holding the results of the window function computed over the partitions
+// of each row, keeping the order of the rows
+func TestQuery_Window(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	age := df.Col("age")
+
+	type testRecord struct {
+		expr windowExpr;
+		expectedName string;
+		// the expected values by first name
+		expected map[string]interface{};
+	}
+
+	testData := []testRecord{
+		{
+			expr: age.Over(PartitionBy("location"), OrderBy(age.Order(ASC))).Apply(ROW_NUMBER),
+			expectedName: "age_row_number",
+			expected: map[string]interface{}{"John": 2, "Jane": 1, "Paul": 1, "Richard": 1, "Reyna": 2, "Ruth": 3},
+		},
+		{
+			expr: age.Over(PartitionBy("last name"), OrderBy(age.Order(ASC))).Apply(CUMSUM),
+			expectedName: "age_cumsum",
+			expected: map[string]interface{}{"John": 49.0, "Jane": 99.0, "Paul": 19.0, "Richard": 34.0, "Reyna": 79.0, "Ruth": 139.0},
+		},
+		{
+			expr: df.Col("first name").Over(PartitionBy("last name"), OrderBy(age.Order(DESC))).Apply(LAG(1)),
+			expectedName: "first name_lag_1",
+			expected: map[string]interface{}{"John": "Jane", "Jane": nil, "Paul": "John", "Richard": "Reyna", "Reyna": "Ruth", "Ruth": nil},
+		},
+		{
+			expr: age.Over(OrderBy(age.Order(ASC))).Apply(LEAD(2)).As("next but one"),
+			expectedName: "next but one",
+			expected: map[string]interface{}{"John": 45, "Jane": nil, "Paul": 34, "Richard": 50, "Reyna": 60, "Ruth": nil},
+		},
+		{
+			expr: age.Over(OrderBy(age.Order(ASC))).Rows(1, 1).Apply(CUMMAX),
+			expectedName: "age_cummax",
+			expected: map[string]interface{}{"John": 34.0, "Jane": 60.0, "Paul": 30.0, "Richard": 45.0, "Reyna": 50.0, "Ruth": 60.0},
+		},
+		{
+			expr: age.Over(PartitionBy("last name")).Rows(UNBOUNDED, UNBOUNDED).Apply(CUMMEAN),
+			expectedName: "age_cummean",
+			expected: map[string]interface{}{"John": 33.0, "Jane": 33.0, "Paul": 33.0, "Richard": 139.0 / 3, "Reyna": 139.0 / 3, "Ruth": 139.0 / 3},
+		},
+		{
+			expr: age.Over(OrderBy(df.Col("last name").Order(ASC))).Apply(RANK),
+			expectedName: "age_rank",
+			expected: map[string]interface{}{"John": 1, "Jane": 1, "Paul": 1, "Richard": 4, "Reyna": 4, "Ruth": 4},
+		},
+		{
+			expr: age.Over(OrderBy(df.Col("last name").Order(DESC))).Apply(DENSE_RANK),
+			expectedName: "age_dense_rank",
+			expected: map[string]interface{}{"John": 2, "Jane": 2, "Paul": 2, "Richard": 1, "Reyna": 1, "Ruth": 1},
+		},
+	}
+
+	for loop, tr := range testData {
+		records, err := df.Select("first name").Window(tr.expr).Execute()
+		if err != nil {
+			t.Fatalf("loop %d, execute error is: %s", loop, err)
+		}
+
+		if len(records) != len(dataArray) {
+			t.Fatalf("loop %d, expected number of records: %d, got %d", loop, len(dataArray), len(records))
+		}
+
+		for i, record := range records {
+			if record["first name"] != dataArray[i]["first name"] {
+				t.Fatalf("loop %d, the record %d expected first name %v, got %v", loop, i, dataArray[i]["first name"], record["first name"])
+			}
+
+			if len(record) != 2 {
+				t.Fatalf("loop %d, the record %d expected 2 fields, got %v", loop, i, record)
+			}
+
+			expectedValue := tr.expected[record["first name"].(string)]
+			if record[tr.expectedName] != expectedValue {
+				t.Fatalf("loop %d, the record %d expected %s: %v, got %v", loop, i, tr.expectedName, expectedValue, record)
+			}
+		}
+	}
+
+	_, err = df.Select().Window(age.Over(PartitionBy("country")).Apply(ROW_NUMBER)).Execute()
+	if err == nil {
+		t.Fatalf("expected an error for a missing partition column")
+	}
+
+	// the rounding errors of a large number should not outlive it in the frame
+	large, err := FromArray([]map[string]interface{}{
+		{"id": 1, "v": 1e20}, {"id": 2, "v": 1.0}, {"id": 3, "v": 1.0}, {"id": 4, "v": 3.0},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("large error is: %s", err)
+	}
+
+	v := large.Col("v")
+	records, err := large.Select("id").Window(
+		v.Over(OrderBy(large.Col("id").Order(ASC))).Rows(1, 0).Apply(CUMSUM).As("sum"),
+		v.Over(OrderBy(large.Col("id").Order(ASC))).Rows(1, 0).Apply(CUMMEAN).As("mean"),
+	).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	expectedSums := []interface{}{1e20, 1e20, 2.0, 4.0}
+	expectedMeans := []interface{}{1e20, 5e19, 1.0, 2.0}
+	for i, record := range records {
+		if record["sum"] != expectedSums[i] || record["mean"] != expectedMeans[i] {
+			t.Fatalf("the record %d expected sum: %v, mean: %v, got %v", i, expectedSums[i], expectedMeans[i], record)
+		}
+	}
+}
+
+// Rolling and Expanding should aggregate the values of each row's frame in the order of the query's SortBy,
+// leaving nil where the frame has too few non-nil values
+func TestColumn_Rolling(t *testing.T)  {
+	df, err := FromArray(dataArray, primaryFields)
+	if err != nil {
+		t.Fatalf("df error is: %s", err)
+	}
+
+	age := df.Col("age")
+	byAge := []string{"Paul", "John", "Richard", "Reyna", "Jane", "Ruth"}
+	byFirstName := []string{"Jane", "John", "Paul", "Reyna", "Richard", "Ruth"}
+
+	type testRecord struct {
+		expr windowExpr;
+		order sortOption;
+		expectedOrder []string;
+		expectedName string;
+		expected []interface{};
+	}
+
+	testData := []testRecord{
+		{
+			expr: age.Rolling(3, SUM),
+			order: age.Order(ASC),
+			expectedOrder: byAge,
+			expectedName: "age_rolling_sum",
+			expected: []interface{}{nil, nil, 83.0, 109.0, 129.0, 155.0},
+		},
+		{
+			expr: age.Rolling(3, SUM).MinPeriods(1),
+			order: age.Order(ASC),
+			expectedOrder: byAge,
+			expectedName: "age_rolling_sum",
+			expected: []interface{}{19.0, 49.0, 83.0, 109.0, 129.0, 155.0},
+		},
+		{
+			expr: age.Rolling(3, SUM).Center(),
+			order: age.Order(ASC),
+			expectedOrder: byAge,
+			expectedName: "age_rolling_sum",
+			expected: []interface{}{nil, 83.0, 109.0, 129.0, 155.0, nil},
+		},
+		{
+			expr: age.Rolling(4, SUM).Center().MinPeriods(1).As("sum of 4"),
+			order: age.Order(ASC),
+			expectedOrder: byAge,
+			expectedName: "sum of 4",
+			expected: []interface{}{49.0, 83.0, 128.0, 159.0, 189.0, 155.0},
+		},
+		{
+			expr: age.Rolling(2, MAX),
+			order: age.Order(DESC),
+			expectedOrder: []string{"Ruth", "Jane", "Reyna", "Richard", "John", "Paul"},
+			expectedName: "age_rolling_max",
+			expected: []interface{}{nil, 60.0, 50.0, 45.0, 34.0, 30.0},
+		},
+		{
+			expr: age.Expanding(SUM),
+			order: df.Col("first name").Order(ASC),
+			expectedOrder: byFirstName,
+			expectedName: "age_expanding_sum",
+			expected: []interface{}{50.0, 80.0, 99.0, 144.0, 178.0, 238.0},
+		},
+		{
+			expr: age.Expanding(COUNT),
+			order: df.Col("first name").Order(ASC),
+			expectedOrder: byFirstName,
+			expectedName: "age_expanding_count",
+			expected: []interface{}{1, 2, 3, 4, 5, 6},
+		},
+	}
+
+	for loop, tr := range testData {
+		records, err := df.Select("first name").SortBy(tr.order).Window(tr.expr).Execute()
+		if err != nil {
+			t.Fatalf("loop %d, execute error is: %s", loop, err)
+		}
+
+		if len(records) != len(tr.expected) {
+			t.Fatalf("loop %d, expected number of records: %d, got %d", loop, len(tr.expected), len(records))
+		}
+
+		for i, record := range records {
+			if record["first name"] != tr.expectedOrder[i] || record[tr.expectedName] != tr.expected[i] {
+				t.Fatalf("loop %d, the record %d expected %s: %v, %s: %v, got %v",
+					loop, i, "first name", tr.expectedOrder[i], tr.expectedName, tr.expected[i], record)
+			}
+		}
+	}
+
+	withNils, err := FromArray([]map[string]interface{}{
+		{"id": 1, "x": 2}, {"id": 2, "x": nil}, {"id": 3, "x": 4}, {"id": 4, "x": nil}, {"id": 5, "x": nil},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("withNils error is: %s", err)
+	}
+
+	records, err := withNils.Select("id").Window(
+		withNils.Col("x").Rolling(3, MEAN).MinPeriods(2),
+		withNils.Col("x").Expanding(MEAN),
+	).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	expectedRolling := []interface{}{nil, nil, 3.0, nil, nil}
+	expectedExpanding := []interface{}{2.0, 2.0, 3.0, 3.0, 3.0}
+	for i, record := range records {
+		if record["x_rolling_mean"] != expectedRolling[i] || record["x_expanding_mean"] != expectedExpanding[i] {
+			t.Fatalf("the record %d expected x_rolling_mean: %v, x_expanding_mean: %v, got %v", i, expectedRolling[i], expectedExpanding[i], record)
+		}
+	}
+
+	// the rounding errors of a large number should not outlive it in the frame
+	large, err := FromArray([]map[string]interface{}{
+		{"id": 1, "v": 1e20}, {"id": 2, "v": 1.0}, {"id": 3, "v": 1.0}, {"id": 4, "v": 3.0},
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("large error is: %s", err)
+	}
+
+	records, err = large.Select("id").Window(
+		large.Col("v").Rolling(2, SUM).MinPeriods(1).As("sum"),
+		large.Col("v").Rolling(2, MEAN).MinPeriods(1).As("mean"),
+	).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	expectedSums := []interface{}{1e20, 1e20, 2.0, 4.0}
+	expectedMeans := []interface{}{1e20, 5e19, 1.0, 2.0}
+	for i, record := range records {
+		if record["sum"] != expectedSums[i] || record["mean"] != expectedMeans[i] {
+			t.Fatalf("the record %d expected sum: %v, mean: %v, got %v", i, expectedSums[i], expectedMeans[i], record)
+		}
+	}
+
+	_, err = df.Select().Window(age.Rolling(0, SUM)).Execute()
+	if err == nil {
+		t.Fatalf("expected an error for a rolling window of 0 rows")
+	}
+
+	// aggregates are named apart, and clashing names are rejected rather than overwritten
+	records, err = df.Select("first name").Window(age.Rolling(2, SUM), age.Rolling(2, MEAN)).Execute()
+	if err != nil {
+		t.Fatalf("execute error is: %s", err)
+	}
+
+	if records[1]["age_rolling_sum"] != 80.0 || records[1]["age_rolling_mean"] != 40.0 {
+		t.Fatalf("expected age_rolling_sum 80 and age_rolling_mean 40, got %v", records[1])
+	}
+
+	for _, exprs := range [][]windowExpr{
+		{age.Rolling(2, PERCENTILE(10)), age.Rolling(2, PERCENTILE(90))},
+		{age.Rolling(2, SUM).As("x"), age.Expanding(MAX).As("x")},
+	} {
+		_, err = df.Select().Window(exprs...).Execute()
+		if err == nil {
+			t.Fatalf("expected an error for window expressions of the same name")
+		}
+	}
+}
+
+// The aggregates kept up to date as the frame slides should give the same results as aggregating each frame afresh,
+// and aggregates of many rows should take neither quadratic time nor time proportional to the size of the frames
+func TestGetFramedAggregate_running(t *testing.T)  {
+	random := rand.New(rand.NewSource(7))
+	numbers := make([]interface{}, 200)
+	for i := range numbers {
+		switch random.Intn(4) {
+		case 0:
+			numbers[i] = nil
+		case 1:
+			numbers[i] = random.Intn(100) - 50
+		default:
+			numbers[i] = random.Float64() * 100
+		}
+	}
+
+	partitions := map[string][]interface{}{
+		"numbers": numbers,
+		"strings": {"b", nil, "a", "d", "c", nil, "a"},
+		"repeated": {3, 3, 3, 3, 3, 1e9, 1e9 + 1, 3, 3},
+		"mixed": {1, "a", nil, 2.5, true, 3},
+		"nils": {nil, nil, nil},
+	}
+
+	aggregates := map[string]aggregateFunc{
+		"SUM": SUM, "MEAN": MEAN, "MAX": MAX, "MIN": MIN, "RANGE": RANGE,
+		"VARIANCE": VARIANCE, "VARIANCE_SAMPLE": VARIANCE_SAMPLE, "STDDEV": STDDEV, "STDDEV_SAMPLE": STDDEV_SAMPLE,
+		"COUNT": COUNT, "COUNT_NON_NULL": COUNT_NON_NULL, "COUNT_DISTINCT": COUNT_DISTINCT, "FIRST": FIRST, "LAST": LAST,
+		"MEDIAN": MEDIAN,
+	}
+	frames := [][2]int{{UNBOUNDED, 0}, {2, 0}, {1, 1}, {0, UNBOUNDED}, {UNBOUNDED, UNBOUNDED}, {5, 3}}
+
+	for partitionName, values := range partitions {
+		for aggName, agg := range aggregates {
+			for _, frame := range frames {
+				for _, minPeriods := range []int{0, 2} {
+					p := windowPartition{values: values, preceding: frame[0], following: frame[1], minPeriods: minPeriods}
+					got := getFramedAggregate("test", agg).fn(&p)
+					expected := getReaggregated(&p, agg)
+
+					for i := range expected {
+						if !isCloseTo(got[i], expected[i]) {
+							t.Fatalf("%s of %s over %v, min periods %d: row %d expected %v, got %v",
+								aggName, partitionName, frame, minPeriods, i, expected[i], got[i])
+						}
+					}
+				}
+			}
+		}
+	}
+
+	many := make([]interface{}, 100000)
+	for i := range many {
+		many[i] = i
+	}
+
+	start := time.Now()
+	p := windowPartition{values: many, preceding: UNBOUNDED}
+	results := CUMSUM.fn(&p)
+	if results[len(many)-1] != float64(len(many)*(len(many)-1)/2) {
+		t.Fatalf("cumulative sum expected %d, got %v", len(many)*(len(many)-1)/2, results[len(many)-1])
+	}
+
+	for _, agg := range []aggregateFunc{STDDEV, MAX, COUNT_DISTINCT} {
+		getFramedAggregate("expanding", agg).fn(&p)
+		p.preceding = 999
+		getFramedAggregate("rolling", agg).fn(&p)
+		p.preceding = UNBOUNDED
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("aggregates of %d rows took %s", len(many), elapsed)
+	}
+}
+
+// Returns whether the values are equal, or are float64 values within rounding errors of each other
+func isCloseTo(first interface{}, second interface{}) bool {
+	firstFloat, isFirstFloat := first.(float64)
+	secondFloat, isSecondFloat := second.(float64)
+	if !isFirstFloat || !isSecondFloat {
+		return first == second
+	}
+
+	return math.Abs(firstFloat-secondFloat) <= 1e-9*math.Max(1, math.Abs(secondFloat))
+}
@@ -101,3 +101,14 @@ func ConvertToStringSlice(slice []interface{}, ignoreNil bool) []string {
 
 	return s[:i]
 }
+
+// Checks to see if the string slice contains the given value
+func ContainsString(slice []string, value string) bool {
+	for _, v := range slice {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -113,4 +113,16 @@ func TestConvertToStringSlice(t *testing.T)  {
 			t.Fatalf("expected %v; got %v", tr.expected, got)
 		}
 	}
-}
\ No newline at end of file
+}
+// ContainsString checks if a given string is in a slice of strings
+func TestContainsString(t *testing.T)  {
+	slice := []string{"foo", "bar", "hi"}
+
+	if !ContainsString(slice, "bar") {
+		t.Fatal("slice should contain 'bar'")
+	}
+
+	if ContainsString(slice, "heyya") {
+		t.Fatal("slice should not contain 'heyya'")
+	}
+}